@@ -38,6 +38,7 @@ var (
 	ErrInvalidStateRoot     = errors.New("invalid block state root")
 	ErrInvalidGasUsed       = errors.New("invalid block gas used")
 	ErrInvalidReceiptsRoot  = errors.New("invalid block receipts root")
+	ErrProcessHeaders       = errors.New("consensus failed to process block header")
 )
 
 // Blockchain is a blockchain reference
@@ -831,7 +832,7 @@ func (b *Blockchain) WriteFullBlock(fblock *types.FullBlock, source string) erro
 
 	// update snapshot
 	if err := b.consensus.ProcessHeaders([]*types.Header{header}); err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrProcessHeaders, err)
 	}
 
 	// Update the average gas price
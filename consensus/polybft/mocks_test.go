@@ -1,6 +1,7 @@
 package polybft
 
 import (
+	"context"
 	"time"
 
 	"github.com/0xPolygon/polygon-edge/blockchain"
@@ -10,6 +11,7 @@ import (
 	"github.com/0xPolygon/polygon-edge/syncer"
 	"github.com/0xPolygon/polygon-edge/types"
 	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/mock"
 	"github.com/umbracle/ethgo"
 	"github.com/umbracle/ethgo/contract"
@@ -389,12 +391,76 @@ func (tp *syncerMock) HasSyncPeer() bool {
 	return args[0].(bool) //nolint
 }
 
+func (tp *syncerMock) HighestKnownBlock() (uint64, peer.ID) {
+	args := tp.Called()
+
+	return args[0].(uint64), args[1].(peer.ID) //nolint
+}
+
+func (tp *syncerMock) Peers() []syncer.PeerInfo {
+	args := tp.Called()
+
+	return args[0].([]syncer.PeerInfo) //nolint
+}
+
+func (tp *syncerMock) PeerSyncProgress(peerID peer.ID) (*syncer.SyncProgress, error) {
+	args := tp.Called(peerID)
+
+	return args.Get(0).(*syncer.SyncProgress), args.Error(1) //nolint
+}
+
 func (tp *syncerMock) Sync(func(*types.FullBlock) bool) error {
 	args := tp.Called()
 
 	return args.Error(0)
 }
 
+func (tp *syncerMock) SyncToTarget(ctx context.Context, target uint64) error {
+	args := tp.Called(ctx, target)
+
+	return args.Error(0)
+}
+
+func (tp *syncerMock) MultiPeerSync(ctx context.Context, target uint64) error {
+	args := tp.Called(ctx, target)
+
+	return args.Error(0)
+}
+
+func (tp *syncerMock) SyncSessions() []*syncer.SyncSession {
+	args := tp.Called()
+
+	return args[0].([]*syncer.SyncSession) //nolint
+}
+
+func (tp *syncerMock) SyncTrace() []syncer.TraceRecord {
+	args := tp.Called()
+
+	return args[0].([]syncer.TraceRecord) //nolint
+}
+
+func (tp *syncerMock) ForceResync(ctx context.Context, peerID peer.ID) error {
+	args := tp.Called(ctx, peerID)
+
+	return args.Error(0)
+}
+
+func (tp *syncerMock) DisconnectPeer(peerID peer.ID) error {
+	args := tp.Called(peerID)
+
+	return args.Error(0)
+}
+
+func (tp *syncerMock) SetPeerAccessLists(allowlist, denylist []peer.ID) {
+	tp.Called(allowlist, denylist)
+}
+
+func (tp *syncerMock) SubscribeImportResults(ctx context.Context) <-chan *syncer.ImportResult {
+	args := tp.Called(ctx)
+
+	return args.Get(0).(<-chan *syncer.ImportResult) //nolint
+}
+
 func init() {
 	// setup custom hash header func
 	setupHeaderHashFunc()
@@ -2,12 +2,16 @@ package progress
 
 import (
 	"sync"
+	"time"
 
 	"github.com/0xPolygon/polygon-edge/blockchain"
 )
 
 type ChainSyncType string
 
+// rateSmoothingFactor is the weight of a new sample in the block rate moving average
+const rateSmoothingFactor = 0.2
+
 const (
 	ChainSyncRestore ChainSyncType = "restore"
 	ChainSyncBulk    ChainSyncType = "bulk-sync"
@@ -28,6 +32,14 @@ type Progression struct {
 
 	// HighestBlock is the target block in the sync batch
 	HighestBlock uint64
+
+	// BlocksPerSecond is the recent rate of writing blocks in the sync batch,
+	// 0 if not known yet
+	BlocksPerSecond float64
+
+	// ETA is the estimated time left until HighestBlock is written,
+	// 0 if the rate is not known yet
+	ETA time.Duration
 }
 
 type ProgressionWrapper struct {
@@ -42,6 +54,14 @@ type ProgressionWrapper struct {
 	lock sync.RWMutex
 
 	syncType ChainSyncType
+
+	// lastUpdate and lastBlock are the time and number of
+	// the previous block rate sample
+	lastUpdate time.Time
+	lastBlock  uint64
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
 }
 
 func NewProgressionWrapper(syncType ChainSyncType) *ProgressionWrapper {
@@ -49,6 +69,7 @@ func NewProgressionWrapper(syncType ChainSyncType) *ProgressionWrapper {
 		progression: nil,
 		stopCh:      make(chan struct{}),
 		syncType:    syncType,
+		now:         time.Now,
 	}
 }
 
@@ -65,6 +86,14 @@ func (pw *ProgressionWrapper) StartProgression(
 		StartingBlock: startingBlock,
 	}
 
+	// the rate is measured from scratch in every sync batch
+	pw.lastUpdate = pw.now()
+	pw.lastBlock = startingBlock
+
+	if startingBlock > 0 {
+		pw.lastBlock = startingBlock - 1
+	}
+
 	go pw.RunUpdateLoop(subscription)
 }
 
@@ -102,20 +131,71 @@ func (pw *ProgressionWrapper) StopProgression() {
 	pw.progression = nil
 }
 
-// UpdateCurrentProgression sets the currently written block in the bulk sync
+// UpdateCurrentProgression sets the currently written block in the bulk sync,
+// it's a no-op if no progression is tracked
 func (pw *ProgressionWrapper) UpdateCurrentProgression(currentBlock uint64) {
 	pw.lock.Lock()
 	defer pw.lock.Unlock()
 
+	if pw.progression == nil {
+		return
+	}
+
 	pw.progression.CurrentBlock = currentBlock
+
+	pw.updateRate(currentBlock)
+	pw.updateETA()
 }
 
-// UpdateHighestProgression sets the highest-known target block in the bulk sync
+// UpdateHighestProgression sets the highest-known target block in the bulk sync,
+// it's a no-op if no progression is tracked
 func (pw *ProgressionWrapper) UpdateHighestProgression(highestBlock uint64) {
 	pw.lock.Lock()
 	defer pw.lock.Unlock()
 
+	if pw.progression == nil {
+		return
+	}
+
 	pw.progression.HighestBlock = highestBlock
+
+	pw.updateETA()
+}
+
+// updateRate adds a sample of the block rate to the moving average
+func (pw *ProgressionWrapper) updateRate(currentBlock uint64) {
+	now := pw.now()
+	elapsed := now.Sub(pw.lastUpdate).Seconds()
+
+	if elapsed <= 0 || currentBlock <= pw.lastBlock {
+		return
+	}
+
+	rate := float64(currentBlock-pw.lastBlock) / elapsed
+
+	if pw.progression.BlocksPerSecond == 0 {
+		pw.progression.BlocksPerSecond = rate
+	} else {
+		pw.progression.BlocksPerSecond = rateSmoothingFactor*rate +
+			(1-rateSmoothingFactor)*pw.progression.BlocksPerSecond
+	}
+
+	pw.lastUpdate = now
+	pw.lastBlock = currentBlock
+}
+
+// updateETA estimates the time left until the highest block is written
+func (pw *ProgressionWrapper) updateETA() {
+	progression := pw.progression
+
+	if progression.BlocksPerSecond == 0 || progression.HighestBlock <= progression.CurrentBlock {
+		progression.ETA = 0
+
+		return
+	}
+
+	remaining := float64(progression.HighestBlock - progression.CurrentBlock)
+	progression.ETA = time.Duration(remaining / progression.BlocksPerSecond * float64(time.Second))
 }
 
 // GetProgression returns the latest sync progression
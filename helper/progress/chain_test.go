@@ -0,0 +1,101 @@
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/blockchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressionWrapper_RateAndETA(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pw  = NewProgressionWrapper(ChainSyncBulk)
+		now = time.Unix(1000, 0)
+	)
+
+	pw.now = func() time.Time {
+		return now
+	}
+
+	pw.StartProgression(1, blockchain.NewMockSubscription())
+	defer pw.StopProgression()
+
+	pw.UpdateHighestProgression(100)
+
+	// rate is unknown until the first block is written
+	assert.Zero(t, pw.GetProgression().BlocksPerSecond)
+	assert.Zero(t, pw.GetProgression().ETA)
+
+	// 10 blocks per second
+	now = now.Add(time.Second)
+	pw.UpdateCurrentProgression(10)
+
+	assert.InDelta(t, 10, pw.GetProgression().BlocksPerSecond, 0.001)
+	assert.InDelta(t, float64(9*time.Second), float64(pw.GetProgression().ETA), float64(time.Millisecond))
+
+	// 20 blocks per second, smoothed with the previous rate
+	now = now.Add(time.Second)
+	pw.UpdateCurrentProgression(30)
+
+	assert.InDelta(t, 12, pw.GetProgression().BlocksPerSecond, 0.001)
+	assert.InDelta(t, float64(70*time.Second)/12, float64(pw.GetProgression().ETA), float64(time.Millisecond))
+
+	// nothing left to write
+	now = now.Add(time.Second)
+	pw.UpdateCurrentProgression(100)
+
+	assert.Zero(t, pw.GetProgression().ETA)
+}
+
+func TestProgressionWrapper_RateResetOnRestart(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pw  = NewProgressionWrapper(ChainSyncBulk)
+		now = time.Unix(1000, 0)
+	)
+
+	pw.now = func() time.Time {
+		return now
+	}
+
+	pw.StartProgression(1, blockchain.NewMockSubscription())
+
+	now = now.Add(time.Second)
+	pw.UpdateCurrentProgression(50)
+
+	assert.InDelta(t, 50, pw.GetProgression().BlocksPerSecond, 0.001)
+
+	pw.StopProgression()
+
+	// new sync batch measures the rate from scratch
+	pw.StartProgression(51, blockchain.NewMockSubscription())
+	defer pw.StopProgression()
+
+	assert.Zero(t, pw.GetProgression().BlocksPerSecond)
+
+	now = now.Add(10 * time.Second)
+	pw.UpdateCurrentProgression(60)
+
+	assert.InDelta(t, 1, pw.GetProgression().BlocksPerSecond, 0.001)
+}
+
+func TestProgressionWrapper_UpdateAfterStop(t *testing.T) {
+	t.Parallel()
+
+	pw := NewProgressionWrapper(ChainSyncBulk)
+
+	pw.StartProgression(1, blockchain.NewMockSubscription())
+	pw.StopProgression()
+
+	// late updates of a stopped progression are dropped
+	assert.NotPanics(t, func() {
+		pw.UpdateCurrentProgression(10)
+		pw.UpdateHighestProgression(100)
+	})
+
+	assert.Nil(t, pw.GetProgression())
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,6 +18,8 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -37,32 +40,77 @@ type syncPeerClient struct {
 	peerStatusUpdateCh     chan *NoForkPeer        // peer status update channel
 	peerConnectionUpdateCh chan *event.PeerEvent   // peer connection update channel
 
-	shouldEmitBlocks bool // flag for emitting blocks in the topic
+	shouldEmitBlocks atomic.Bool // flag for emitting blocks in the topic
 	closeCh          chan struct{}
 	closed           atomic.Bool
 
+	// interval of publishing own status regardless of new blocks, 0 disables it
+	statusPublishInterval time.Duration
+	// latest block number received in a blockchain event
+	lastEventNumber atomic.Uint64
+
+	// bytes of blocks received from peers
+	downloadedBytes bytesCounter
+
+	// maximum bytes of blocks per second received from a single peer, 0 means unlimited
+	peerDownloadLimit int
+	// peer ID -> *rate.Limiter
+	peerDownloadLimiters sync.Map
+	// block channel returned by GetBlocks -> *atomic.Int32, number of blocks received on the stream
+	// but not passed on yet, which are only held back by the peer's limiter
+	throttledStreams sync.Map
+
+	// concurrent status requests to the same peer share a single RPC
+	statusRequests singleflight.Group
+
 	peerStatusUpdateChLock   sync.Mutex
 	peerStatusUpdateChClosed bool
 }
 
+type SyncPeerClientOption func(*syncPeerClient)
+
+// WithStatusPublishInterval sets how often own status is published even if there is no new block.
+// Periodic publishing is disabled by default and with zero value
+func WithStatusPublishInterval(interval time.Duration) SyncPeerClientOption {
+	return func(m *syncPeerClient) {
+		m.statusPublishInterval = interval
+	}
+}
+
+// WithPeerDownloadLimit caps the bytes of blocks per second received from a single peer,
+// zero value means unlimited
+func WithPeerDownloadLimit(bytesPerSecond int) SyncPeerClientOption {
+	return func(m *syncPeerClient) {
+		m.peerDownloadLimit = bytesPerSecond
+	}
+}
+
 func NewSyncPeerClient(
 	logger hclog.Logger,
 	network Network,
 	blockchain Blockchain,
+	opts ...SyncPeerClientOption,
 ) SyncPeerClient {
-	return &syncPeerClient{
+	client := &syncPeerClient{
 		logger:                 logger.Named(SyncPeerClientLoggerName),
 		network:                network,
 		blockchain:             blockchain,
 		id:                     network.AddrInfo().ID.String(),
 		peerStatusUpdateCh:     make(chan *NoForkPeer, 1),
 		peerConnectionUpdateCh: make(chan *event.PeerEvent, 1),
-		shouldEmitBlocks:       true,
 		closeCh:                make(chan struct{}),
 
 		peerStatusUpdateChLock:   sync.Mutex{},
 		peerStatusUpdateChClosed: false,
 	}
+
+	client.shouldEmitBlocks.Store(true)
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
 }
 
 // Start processes for SyncPeerClient
@@ -77,6 +125,10 @@ func (m *syncPeerClient) Start() error {
 		return err
 	}
 
+	if m.statusPublishInterval > 0 {
+		go m.startStatusPublishProcess()
+	}
+
 	return nil
 }
 
@@ -109,36 +161,122 @@ func (m *syncPeerClient) Close() {
 
 // DisablePublishingPeerStatus disables publishing own status via gossip
 func (m *syncPeerClient) DisablePublishingPeerStatus() {
-	m.shouldEmitBlocks = false
+	m.shouldEmitBlocks.Store(false)
 }
 
 // EnablePublishingPeerStatus enables publishing own status via gossip
 func (m *syncPeerClient) EnablePublishingPeerStatus() {
-	m.shouldEmitBlocks = true
+	m.shouldEmitBlocks.Store(true)
 }
 
-// GetPeerStatus fetches peer status
+// GetPeerStatus fetches peer status, concurrent calls for the same peer share a single request
 func (m *syncPeerClient) GetPeerStatus(peerID peer.ID) (*NoForkPeer, error) {
-	clt, err := m.newSyncPeerClient(peerID)
+	value, err, _ := m.statusRequests.Do(peerID.String(), func() (interface{}, error) {
+		return m.requestPeerStatus(peerID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), defaultTimeoutForStatus)
-	defer cancel()
+	status, _ := value.(*NoForkPeer)
 
-	status, err := clt.GetStatus(timeoutCtx, &emptypb.Empty{})
-	if err != nil {
+	// the result is shared between the callers, give each one its own copy
+	statusCopy := &NoForkPeer{
+		ID:           status.ID,
+		Number:       status.Number,
+		Capabilities: append([]string(nil), status.Capabilities...),
+	}
+
+	if status.Distance != nil {
+		statusCopy.Distance = new(big.Int).Set(status.Distance)
+	}
+
+	return statusCopy, nil
+}
+
+// requestPeerStatus requests the status of the peer via RPC
+func (m *syncPeerClient) requestPeerStatus(peerID peer.ID) (*NoForkPeer, error) {
+	var status *proto.SyncPeerStatus
+
+	if err := m.callPeer(peerID, func(ctx context.Context, clt proto.SyncPeerClient) (err error) {
+		status, err = clt.GetStatus(ctx, &emptypb.Empty{})
+
+		return err
+	}); err != nil {
 		return nil, err
 	}
 
 	return &NoForkPeer{
-		ID:       peerID,
-		Number:   status.Number,
-		Distance: m.network.GetPeerDistance(peerID),
+		ID:           peerID,
+		Number:       status.Number,
+		Distance:     m.network.GetPeerDistance(peerID),
+		Capabilities: status.Capabilities,
 	}, nil
 }
 
+// GetPeerSyncProgress requests the sync progress the peer reports of itself
+func (m *syncPeerClient) GetPeerSyncProgress(peerID peer.ID) (*SyncProgress, error) {
+	var progress *proto.SyncProgress
+
+	if err := m.callPeer(peerID, func(ctx context.Context, clt proto.SyncPeerClient) (err error) {
+		progress, err = clt.GetSyncProgress(ctx, &emptypb.Empty{})
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &SyncProgress{
+		Head:    progress.Head,
+		Highest: progress.Highest,
+		Syncing: progress.Syncing,
+	}, nil
+}
+
+// Ping pings the peer and returns the round-trip time of the call, opening the stream isn't counted
+func (m *syncPeerClient) Ping(peerID peer.ID) (time.Duration, error) {
+	var rtt time.Duration
+
+	if err := m.callPeer(peerID, func(ctx context.Context, clt proto.SyncPeerClient) error {
+		start := time.Now()
+
+		if _, err := clt.Ping(ctx, &emptypb.Empty{}); err != nil {
+			return err
+		}
+
+		rtt = time.Since(start)
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return rtt, nil
+}
+
+// callPeer runs the unary call to the peer within the status timeout. The call has a stream of its own
+// closed on return, so that such calls don't pile up connections or replace the stream of a bulk sync
+func (m *syncPeerClient) callPeer(
+	peerID peer.ID,
+	call func(context.Context, proto.SyncPeerClient) error,
+) error {
+	conn, err := m.network.NewProtoConnection(syncerProto, peerID)
+	if err != nil {
+		return fmt.Errorf("failed to open a stream, err %w", err)
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			m.logger.Debug("failed to close stream", "peer", peerID, "err", err)
+		}
+	}()
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), defaultTimeoutForStatus)
+	defer cancel()
+
+	return call(timeoutCtx, proto.NewSyncPeerClient(conn))
+}
+
 // GetConnectedPeerStatuses fetches the statuses of all connecting peers
 func (m *syncPeerClient) GetConnectedPeerStatuses() []*NoForkPeer {
 	var (
@@ -226,9 +364,10 @@ func (m *syncPeerClient) handleStatusUpdate(obj interface{}, from peer.ID) {
 
 	if !m.peerStatusUpdateChClosed {
 		m.peerStatusUpdateCh <- &NoForkPeer{
-			ID:       from,
-			Number:   status.Number,
-			Distance: m.network.GetPeerDistance(from),
+			ID:           from,
+			Number:       status.Number,
+			Distance:     m.network.GetPeerDistance(from),
+			Capabilities: status.Capabilities,
 		}
 	}
 }
@@ -247,22 +386,89 @@ func (m *syncPeerClient) startNewBlockProcess() {
 		case event = <-eventCh:
 		}
 
-		if !m.shouldEmitBlocks {
+		l := len(event.NewChain)
+		if l == 0 {
 			continue
 		}
 
-		if l := len(event.NewChain); l > 0 {
-			latest := event.NewChain[l-1]
-			// Publish status
-			if err := m.topic.Publish(&proto.SyncPeerStatus{
-				Number: latest.Number,
-			}); err != nil {
-				m.logger.Warn("failed to publish status", "err", err)
-			}
+		latest := event.NewChain[l-1]
+		m.lastEventNumber.Store(latest.Number)
+
+		if m.shouldEmitBlocks.Load() {
+			m.publishStatus(latest.Number)
 		}
 	}
 }
 
+// eventStallDetector detects blocks written without a blockchain event,
+// which means own status is only published periodically instead of on every block
+type eventStallDetector struct {
+	// header number at the previous check
+	lastHeaderNumber uint64
+	// number the header advanced to before the previous check, an event for it is expected by now
+	expectedEventNumber uint64
+}
+
+// check takes the current header number and the latest block number received in an event,
+// it returns true if the event for a block written before the previous check is still missing
+func (d *eventStallDetector) check(headerNumber, lastEventNumber uint64) bool {
+	stalled := d.expectedEventNumber > lastEventNumber
+
+	d.expectedEventNumber = 0
+	if headerNumber > d.lastHeaderNumber {
+		d.expectedEventNumber = headerNumber
+	}
+
+	d.lastHeaderNumber = headerNumber
+
+	return stalled
+}
+
+// startStatusPublishProcess publishes own status periodically so that peers
+// get to know the latest status even if no new block is produced or blockchain events stop coming,
+// it warns about the latter
+func (m *syncPeerClient) startStatusPublishProcess() {
+	ticker := time.NewTicker(m.statusPublishInterval)
+	defer ticker.Stop()
+
+	stallDetector := &eventStallDetector{}
+	if header := m.blockchain.Header(); header != nil {
+		stallDetector.lastHeaderNumber = header.Number
+	}
+
+	for {
+		select {
+		case <-m.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		header := m.blockchain.Header()
+		if header == nil {
+			continue
+		}
+
+		if stallDetector.check(header.Number, m.lastEventNumber.Load()) {
+			m.logger.Warn("no blockchain event received for new blocks, status is only published periodically",
+				"latest", header.Number, "lastEvent", m.lastEventNumber.Load())
+		}
+
+		if m.shouldEmitBlocks.Load() {
+			m.publishStatus(header.Number)
+		}
+	}
+}
+
+// publishStatus publishes own latest block number in the status topic
+func (m *syncPeerClient) publishStatus(number uint64) {
+	if err := m.topic.Publish(&proto.SyncPeerStatus{
+		Number:       number,
+		Capabilities: capabilities,
+	}); err != nil {
+		m.logger.Warn("failed to publish status", "err", err)
+	}
+}
+
 // startPeerEventProcess starts subscribing peer connection change events and process them
 func (m *syncPeerClient) startPeerEventProcess() {
 	defer close(m.peerConnectionUpdateCh)
@@ -281,6 +487,11 @@ func (m *syncPeerClient) startPeerEventProcess() {
 
 		case e := <-peerEventCh:
 			if e != nil && (e.Type == event.PeerConnected || e.Type == event.PeerDisconnected) {
+				if e.Type == event.PeerDisconnected {
+					m.downloadedBytes.remove(e.PeerID)
+					m.peerDownloadLimiters.Delete(e.PeerID)
+				}
+
 				m.peerConnectionUpdateCh <- e
 			}
 		}
@@ -294,6 +505,7 @@ func (m *syncPeerClient) CloseStream(peerID peer.ID) error {
 
 // GetBlocks returns a stream of blocks from given height to peer's latest
 func (m *syncPeerClient) GetBlocks(
+	ctx context.Context,
 	peerID peer.ID,
 	from uint64,
 	timeoutPerBlock time.Duration,
@@ -303,7 +515,8 @@ func (m *syncPeerClient) GetBlocks(
 		return nil, fmt.Errorf("failed to create sync peer client: %w", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// cancellation of the given context is propagated to the peer via gRPC
+	ctx, cancel := context.WithCancel(ctx)
 
 	stream, err := clt.GetBlocks(ctx, &proto.GetBlocksRequest{
 		From: from,
@@ -314,13 +527,25 @@ func (m *syncPeerClient) GetBlocks(
 		return nil, fmt.Errorf("failed to open GetBlocks stream: %w", err)
 	}
 
+	// blocks received from the peer but not passed on yet, tracked per stream
+	// so that a stall of this stream isn't hidden by another throttled stream from the peer
+	pending := new(atomic.Int32)
+
 	// input channel
-	streamBlockCh, streamErrorCh := blockStreamToChannel(stream)
+	streamBlockCh, streamErrorCh := blockStreamToChannel(stream, func(size uint64) error {
+		m.downloadedBytes.add(peerID, size)
+		pending.Add(1)
+
+		return m.throttleDownload(ctx, peerID, size)
+	})
 
 	// output channel
 	blockCh := make(chan *types.Block, 1)
 
+	m.throttledStreams.Store((<-chan *types.Block)(blockCh), pending)
+
 	go func() {
+		defer m.throttledStreams.Delete((<-chan *types.Block)(blockCh))
 		defer cancel()
 		defer close(blockCh)
 
@@ -331,12 +556,24 @@ func (m *syncPeerClient) GetBlocks(
 					return
 				}
 
-				blockCh <- block
+				select {
+				case blockCh <- block:
+					pending.Add(-1)
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
 			case err := <-streamErrorCh:
 				m.logger.Error("failed to get block from gRPC stream", "peer", peerID, "err", err)
 
 				return
 			case <-time.After(timeoutPerBlock):
+				// the block is held back by our own download limit, not by the peer
+				if pending.Load() > 0 {
+					continue
+				}
+
 				m.logger.Warn("block doesn't reach within timeout", "timeout", timeoutPerBlock)
 
 				return
@@ -369,7 +606,66 @@ func fromProto(protoBlock *proto.Block) (*types.Block, error) {
 	return block, nil
 }
 
-func blockStreamToChannel(stream proto.SyncPeer_GetBlocksClient) (<-chan *types.Block, <-chan error) {
+// DownloadedBytes returns the number of bytes of blocks received from the connected peer
+func (m *syncPeerClient) DownloadedBytes(peerID peer.ID) uint64 {
+	return m.downloadedBytes.get(peerID)
+}
+
+// TotalDownloadedBytes returns the number of bytes of blocks received from all peers
+func (m *syncPeerClient) TotalDownloadedBytes() uint64 {
+	return m.downloadedBytes.total.Load()
+}
+
+// throttleDownload waits until the bytes received from the peer fit in the peer download limit,
+// returns error if ctx is done first
+func (m *syncPeerClient) throttleDownload(ctx context.Context, peerID peer.ID, size uint64) error {
+	if m.peerDownloadLimit <= 0 {
+		return nil
+	}
+
+	value, _ := m.peerDownloadLimiters.LoadOrStore(
+		peerID,
+		rate.NewLimiter(rate.Limit(m.peerDownloadLimit), m.peerDownloadLimit),
+	)
+	limiter, _ := value.(*rate.Limiter)
+
+	// a block may exceed the burst, wait for it in chunks
+	for size > 0 {
+		n := size
+		if n > uint64(limiter.Burst()) {
+			n = uint64(limiter.Burst())
+		}
+
+		if err := limiter.WaitN(ctx, int(n)); err != nil {
+			return err
+		}
+
+		size -= n
+	}
+
+	return nil
+}
+
+// IsThrottled returns true if a block received on the stream returned by GetBlocks
+// is held back by the peer's download limit
+func (m *syncPeerClient) IsThrottled(blockCh <-chan *types.Block) bool {
+	value, ok := m.throttledStreams.Load(blockCh)
+	if !ok {
+		return false
+	}
+
+	pending, _ := value.(*atomic.Int32)
+
+	return pending.Load() > 0
+}
+
+// blockStreamToChannel reads blocks from the stream into the channel,
+// onReceive is called with the size of every received block before it's decoded,
+// reading stops if it returns error
+func blockStreamToChannel(
+	stream proto.SyncPeer_GetBlocksClient,
+	onReceive func(size uint64) error,
+) (<-chan *types.Block, <-chan error) {
 	blockCh := make(chan *types.Block)
 	errorCh := make(chan error, 1)
 
@@ -389,6 +685,10 @@ func blockStreamToChannel(stream proto.SyncPeer_GetBlocksClient) (<-chan *types.
 				break
 			}
 
+			if err := onReceive(uint64(len(protoBlock.Block))); err != nil {
+				return
+			}
+
 			block, err := fromProto(protoBlock)
 			if err != nil {
 				metrics.IncrCounter([]string{syncerMetrics, "bad_block"}, 1)
@@ -398,8 +698,13 @@ func blockStreamToChannel(stream proto.SyncPeer_GetBlocksClient) (<-chan *types.
 			}
 
 			metrics.SetGauge([]string{syncerMetrics, "ingress_bytes"}, float32(len(protoBlock.Block)))
+			metrics.IncrCounter([]string{syncerMetrics, "downloaded_bytes"}, float32(len(protoBlock.Block)))
 
-			blockCh <- block
+			select {
+			case blockCh <- block:
+			case <-stream.Context().Done():
+				return
+			}
 		}
 	}()
 
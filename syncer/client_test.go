@@ -3,6 +3,7 @@ package syncer
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -93,14 +94,149 @@ func TestGetPeerStatus(t *testing.T) {
 	assert.NoError(t, err)
 
 	expected := &NoForkPeer{
-		ID:       peerSrv.AddrInfo().ID,
-		Number:   peerLatest,
-		Distance: clientSrv.GetPeerDistance(peerSrv.AddrInfo().ID),
+		ID:           peerSrv.AddrInfo().ID,
+		Number:       peerLatest,
+		Distance:     clientSrv.GetPeerDistance(peerSrv.AddrInfo().ID),
+		Capabilities: []string{FeatureSyncProgress, FeaturePing},
 	}
 
 	assert.Equal(t, expected, status)
 }
 
+func TestGetPeerSyncProgress(t *testing.T) {
+	t.Parallel()
+
+	clientSrv := newTestNetwork(t)
+	client := newTestSyncPeerClient(clientSrv, nil)
+
+	peerSrv := newTestNetwork(t)
+	service := &syncPeerService{
+		blockchain: &mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(10),
+		},
+		network: peerSrv,
+		syncProgress: func() (uint64, bool) {
+			return 20, true
+		},
+	}
+
+	service.Start()
+
+	err := network.JoinAndWait(
+		clientSrv,
+		peerSrv,
+		network.DefaultBufferTimeout,
+		network.DefaultJoinTimeout,
+	)
+
+	require.NoError(t, err)
+
+	progress, err := client.GetPeerSyncProgress(peerSrv.AddrInfo().ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, &SyncProgress{Head: 10, Highest: 20, Syncing: true}, progress)
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	clientSrv := newTestNetwork(t)
+	client := newTestSyncPeerClient(clientSrv, nil)
+
+	_, peerSrv := createTestSyncerService(t, &mockBlockchain{})
+
+	err := network.JoinAndWait(
+		clientSrv,
+		peerSrv,
+		network.DefaultBufferTimeout,
+		network.DefaultJoinTimeout,
+	)
+
+	require.NoError(t, err)
+
+	rtt, err := client.Ping(peerSrv.AddrInfo().ID)
+
+	assert.NoError(t, err)
+	assert.Positive(t, rtt)
+	assert.Less(t, rtt, defaultTimeoutForStatus)
+}
+
+func TestGetPeerStatus_Coalesced(t *testing.T) {
+	t.Parallel()
+
+	var (
+		peerLatest = uint64(10)
+		rpcCalls   atomic.Int64
+		callers    = 20
+	)
+
+	clientSrv := newTestNetwork(t)
+	client := newTestSyncPeerClient(clientSrv, nil)
+
+	_, peerSrv := createTestSyncerService(t, &mockBlockchain{
+		headerHandler: func() *types.Header {
+			rpcCalls.Add(1)
+
+			// keep the request in flight until all the callers joined it
+			time.Sleep(200 * time.Millisecond)
+
+			return &types.Header{Number: peerLatest}
+		},
+	})
+
+	err := network.JoinAndWait(
+		clientSrv,
+		peerSrv,
+		network.DefaultBufferTimeout,
+		network.DefaultJoinTimeout,
+	)
+
+	require.NoError(t, err)
+
+	var (
+		wg       sync.WaitGroup
+		start    = make(chan struct{})
+		statuses = make([]*NoForkPeer, callers)
+		errs     = make([]error, callers)
+	)
+
+	for i := 0; i < callers; i++ {
+		i := i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+
+			statuses[i], errs[i] = client.GetPeerStatus(peerSrv.AddrInfo().ID)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), rpcCalls.Load())
+
+	for i := 0; i < callers; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, peerLatest, statuses[i].Number)
+	}
+
+	// the copies don't share the distance, so that updating one doesn't change the others
+	if assert.NotNil(t, statuses[0].Distance) {
+		for i := 1; i < callers; i++ {
+			assert.NotSame(t, statuses[0].Distance, statuses[i].Distance)
+		}
+	}
+
+	// a later call makes a new request
+	_, err = client.GetPeerStatus(peerSrv.AddrInfo().ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), rpcCalls.Load())
+}
+
 func TestGetConnectedPeerStatuses(t *testing.T) {
 	t.Parallel()
 
@@ -146,9 +282,10 @@ func TestGetConnectedPeerStatuses(t *testing.T) {
 			)
 
 			expected[idx] = &NoForkPeer{
-				ID:       peerID,
-				Number:   latest,
-				Distance: clientSrv.GetPeerDistance(peerID),
+				ID:           peerID,
+				Number:       latest,
+				Distance:     clientSrv.GetPeerDistance(peerID),
+				Capabilities: []string{FeatureSyncProgress, FeaturePing},
 			}
 		}()
 	}
@@ -510,7 +647,7 @@ func Test_syncPeerClient_GetBlocks(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	blockStream, err := client.GetBlocks(peerSrv.AddrInfo().ID, syncFrom, 5*time.Second)
+	blockStream, err := client.GetBlocks(context.Background(), peerSrv.AddrInfo().ID, syncFrom, 5*time.Second)
 	assert.NoError(t, err)
 
 	blocks := make([]*types.Block, 0, peerLatest)
@@ -520,11 +657,77 @@ func Test_syncPeerClient_GetBlocks(t *testing.T) {
 
 	// hash is calculated on unmarshaling
 	expected := createMockBlocks(10)
+	expectedBytes := uint64(0)
+
 	for _, b := range expected {
+		expectedBytes += uint64(len(b.MarshalRLP()))
+
 		b.Header.ComputeHash()
 	}
 
 	assert.Equal(t, expected, blocks)
+	assert.Equal(t, expectedBytes, client.DownloadedBytes(peerSrv.AddrInfo().ID))
+	assert.Equal(t, expectedBytes, client.TotalDownloadedBytes())
+}
+
+func Test_syncPeerClient_GetBlocks_DownloadLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		peerLatest = uint64(10)
+		blockSize  = len(createMockBlocks(1)[0].MarshalRLP())
+
+		clientSrv = newTestNetwork(t)
+		client    = newTestSyncPeerClient(clientSrv, nil)
+	)
+
+	// 4 blocks fit in the burst, each other block waits a quarter of a second for the limit
+	WithPeerDownloadLimit(4 * blockSize)(client)
+
+	_, peerSrv := createTestSyncerService(t, &mockBlockchain{
+		headerHandler: newSimpleHeaderHandler(peerLatest),
+		getBlockByNumberHandler: func(u uint64, b bool) (*types.Block, bool) {
+			if u <= peerLatest {
+				return &types.Block{
+					Header: &types.Header{
+						Number: u,
+					},
+				}, true
+			}
+
+			return nil, false
+		},
+	})
+
+	err := network.JoinAndWait(
+		clientSrv,
+		peerSrv,
+		network.DefaultBufferTimeout,
+		network.DefaultJoinTimeout,
+	)
+
+	require.NoError(t, err)
+
+	blockStream, err := client.GetBlocks(context.Background(), peerSrv.AddrInfo().ID, 1, 100*time.Millisecond)
+	require.NoError(t, err)
+
+	throttled := false
+	blocks := 0
+
+	for range blockStream {
+		blocks++
+
+		throttled = throttled || client.IsThrottled(blockStream)
+	}
+
+	// the blocks held back by the limit longer than the timeout don't end the stream
+	assert.Equal(t, int(peerLatest), blocks)
+	assert.True(t, throttled)
+
+	// the stream is forgotten once it's closed
+	assert.Eventually(t, func() bool {
+		return !client.IsThrottled(blockStream)
+	}, time.Second, 10*time.Millisecond)
 }
 
 func Test_EmitMultipleBlocks(t *testing.T) {
@@ -627,3 +830,158 @@ func Test_EmitMultipleBlocks(t *testing.T) {
 		testGossip(t, 4)
 	})
 }
+
+func Test_startStatusPublishProcess(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// network layer
+		clientSrv = newTestNetwork(t)
+		peerSrv   = newTestNetwork(t)
+
+		clientLatest atomic.Uint64
+
+		client = newTestSyncPeerClient(clientSrv, &mockBlockchain{
+			headerHandler: func() *types.Header {
+				return &types.Header{Number: clientLatest.Load()}
+			},
+		})
+	)
+
+	clientLatest.Store(10)
+
+	client.statusPublishInterval = 500 * time.Millisecond
+	client.EnablePublishingPeerStatus()
+
+	t.Cleanup(func() {
+		clientSrv.Close()
+		peerSrv.Close()
+		client.Close()
+	})
+
+	err := network.JoinAndWaitMultiple(
+		network.DefaultJoinTimeout,
+		clientSrv,
+		peerSrv,
+	)
+
+	require.NoError(t, err)
+
+	require.NoError(t, client.startGossip())
+
+	// create topic & subscribe in peer
+	topic, err := peerSrv.NewTopic(statusTopicName, &proto.SyncPeerStatus{})
+	require.NoError(t, err)
+
+	receivedCh := make(chan uint64, 16)
+
+	require.NoError(t, topic.Subscribe(func(obj interface{}, _ peer.ID) {
+		status, ok := obj.(*proto.SyncPeerStatus)
+		if !ok {
+			return
+		}
+
+		select {
+		case receivedCh <- status.Number:
+		default:
+		}
+	}))
+
+	// need to wait for a few seconds to propagate subscribing
+	time.Sleep(2 * time.Second)
+
+	go client.startStatusPublishProcess()
+
+	// local status advances without any blockchain event
+	clientLatest.Store(11)
+
+	timeoutCh := time.After(5 * time.Second)
+
+	for {
+		select {
+		case number := <-receivedCh:
+			if number == 11 {
+				return
+			}
+		case <-timeoutCh:
+			t.Fatal("peer didn't receive the updated status")
+		}
+	}
+}
+
+func Test_throttleDownload(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not wait if download isn't limited", func(t *testing.T) {
+		t.Parallel()
+
+		client := &syncPeerClient{}
+
+		start := time.Now()
+
+		assert.NoError(t, client.throttleDownload(context.Background(), peer.ID("A"), 1<<20))
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("should keep download rate of a peer under the limit", func(t *testing.T) {
+		t.Parallel()
+
+		client := &syncPeerClient{}
+		WithPeerDownloadLimit(1000)(client)
+
+		start := time.Now()
+
+		// first 1000 bytes fit in the burst, other 500 bytes take half a second
+		assert.NoError(t, client.throttleDownload(context.Background(), peer.ID("A"), 1500))
+		assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+		// other peer has its own limit
+		start = time.Now()
+
+		assert.NoError(t, client.throttleDownload(context.Background(), peer.ID("B"), 1000))
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("should return once context is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		client := &syncPeerClient{}
+		WithPeerDownloadLimit(1000)(client)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// use up the burst
+		assert.NoError(t, client.throttleDownload(ctx, peer.ID("A"), 1000))
+
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+
+		assert.Error(t, client.throttleDownload(ctx, peer.ID("A"), 100000))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func Test_eventStallDetector(t *testing.T) {
+	t.Parallel()
+
+	detector := &eventStallDetector{lastHeaderNumber: 10}
+
+	// no new block
+	assert.False(t, detector.check(10, 10))
+
+	// block 12 was just written, its event may still be on the way
+	assert.False(t, detector.check(12, 10))
+
+	// event arrived in time
+	assert.False(t, detector.check(12, 12))
+
+	// blocks keep being written with the event channel silent
+	assert.False(t, detector.check(13, 12))
+	assert.True(t, detector.check(15, 12))
+	assert.True(t, detector.check(16, 12))
+
+	// events resumed
+	assert.False(t, detector.check(16, 16))
+	assert.False(t, detector.check(16, 16))
+}
@@ -0,0 +1,41 @@
+package syncer
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// SyncPhase is the step of a bulk sync session
+type SyncPhase string
+
+const (
+	// SyncPhaseStart is starting the session and requesting blocks from the peer
+	SyncPhaseStart SyncPhase = "start"
+	// SyncPhaseDownload is receiving blocks from the peer
+	SyncPhaseDownload SyncPhase = "download"
+	// SyncPhaseVerify is verifying a received block
+	SyncPhaseVerify SyncPhase = "verify"
+	// SyncPhaseWrite is writing a verified block to the local chain
+	SyncPhaseWrite SyncPhase = "write"
+)
+
+// SyncError is the error of a bulk sync session with the peer and block it failed on
+type SyncError struct {
+	// PeerID is the ID of the peer the session was with
+	PeerID peer.ID
+	// Phase is the step the session failed at
+	Phase SyncPhase
+	// Number is the number of the block the session failed on
+	Number uint64
+	// Err is the cause of the failure
+	Err error
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("sync with peer %s failed to %s block %d: %v", e.PeerID, e.Phase, e.Number, e.Err)
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,73 @@
+package syncer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// importResultBufferSize is the number of results buffered per subscriber,
+	// results are dropped for a subscriber whose buffer is full
+	importResultBufferSize = 64
+)
+
+// ImportResult is the result of an attempt to import a block received from a peer
+type ImportResult struct {
+	// PeerID is the ID of the peer the block was received from
+	PeerID peer.ID
+	// Number is the number of the block
+	Number uint64
+	// Hash is the hash of the block
+	Hash types.Hash
+	// Err is the reason the block wasn't imported, nil if the block was imported
+	Err error
+}
+
+// importResultFeed dispatches import results to the subscribers
+type importResultFeed struct {
+	lock sync.Mutex
+	subs map[chan *ImportResult]struct{}
+}
+
+// subscribe returns a channel of import results, the channel is closed once ctx is done
+func (f *importResultFeed) subscribe(ctx context.Context) <-chan *ImportResult {
+	ch := make(chan *ImportResult, importResultBufferSize)
+
+	f.lock.Lock()
+
+	if f.subs == nil {
+		f.subs = make(map[chan *ImportResult]struct{})
+	}
+
+	f.subs[ch] = struct{}{}
+
+	f.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		delete(f.subs, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+// emit sends the result to all the subscribers without blocking
+func (f *importResultFeed) emit(result *ImportResult) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for ch := range f.subs {
+		select {
+		case ch <- result:
+		default:
+		}
+	}
+}
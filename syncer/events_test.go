@@ -0,0 +1,45 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_importResultFeed(t *testing.T) {
+	t.Parallel()
+
+	feed := new(importResultFeed)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+
+	ch1 := feed.subscribe(ctx1)
+	ch2 := feed.subscribe(ctx2)
+
+	result := &ImportResult{Number: 1}
+	feed.emit(result)
+
+	assert.Same(t, result, <-ch1)
+	assert.Same(t, result, <-ch2)
+
+	// canceled subscription gets closed and no longer receives results
+	cancel1()
+
+	_, ok := <-ch1
+	assert.False(t, ok)
+
+	feed.emit(&ImportResult{Number: 2})
+
+	assert.Equal(t, uint64(2), (<-ch2).Number)
+
+	// emit doesn't block on a subscriber that falls behind
+	for i := 0; i < importResultBufferSize+1; i++ {
+		feed.emit(&ImportResult{Number: uint64(i)})
+	}
+
+	assert.Len(t, ch2, importResultBufferSize)
+
+	cancel2()
+}
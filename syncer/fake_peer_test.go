@@ -0,0 +1,322 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSyncPeer is an in-memory peer serving its own chain, with knobs for latency,
+// error injection and lying about its height
+type fakeSyncPeer struct {
+	id peer.ID
+	// chain of the peer, block i has number i+1
+	blocks []*types.Block
+	// latest block number the peer advertises instead of its real one, 0 means the real one
+	advertisedNumber uint64
+	// delay before a status response and before each streamed block
+	latency time.Duration
+	// errors returned by status and blocks requests
+	statusErr    error
+	getBlocksErr error
+
+	lock           sync.Mutex
+	statusCalls    int
+	getBlocksCalls int
+}
+
+// status returns the status the peer advertises
+func (p *fakeSyncPeer) status() (*NoForkPeer, error) {
+	p.lock.Lock()
+	p.statusCalls++
+	p.lock.Unlock()
+
+	time.Sleep(p.latency)
+
+	if p.statusErr != nil {
+		return nil, p.statusErr
+	}
+
+	number := uint64(len(p.blocks))
+	if p.advertisedNumber > 0 {
+		number = p.advertisedNumber
+	}
+
+	return &NoForkPeer{ID: p.id, Number: number, Distance: big.NewInt(0)}, nil
+}
+
+// getBlocks streams the blocks of the peer's chain starting from the given number
+func (p *fakeSyncPeer) getBlocks(from uint64) (<-chan *types.Block, error) {
+	p.lock.Lock()
+	p.getBlocksCalls++
+	p.lock.Unlock()
+
+	if p.getBlocksErr != nil {
+		return nil, p.getBlocksErr
+	}
+
+	if from == 0 || from > uint64(len(p.blocks)) {
+		return blocksToCh(nil, 0), nil
+	}
+
+	return blocksToCh(p.blocks[from-1:], p.latency), nil
+}
+
+// calls returns the number of status and blocks requests the peer received
+func (p *fakeSyncPeer) calls() (int, int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.statusCalls, p.getBlocksCalls
+}
+
+// newFakeSyncPeerClient returns a sync peer client serving the requests from the fake peers
+func newFakeSyncPeerClient(peers ...*fakeSyncPeer) *mockSyncPeerClient {
+	byID := make(map[peer.ID]*fakeSyncPeer, len(peers))
+	for _, p := range peers {
+		byID[p.id] = p
+	}
+
+	errUnknownPeer := errors.New("unknown peer")
+
+	return &mockSyncPeerClient{
+		getPeerStatusHandler: func(id peer.ID) (*NoForkPeer, error) {
+			p, ok := byID[id]
+			if !ok {
+				return nil, errUnknownPeer
+			}
+
+			return p.status()
+		},
+		getConnectedPeerStatusesHandler: func() []*NoForkPeer {
+			statuses := make([]*NoForkPeer, 0, len(peers))
+
+			for _, p := range peers {
+				if status, err := p.status(); err == nil {
+					statuses = append(statuses, status)
+				}
+			}
+
+			return statuses
+		},
+		getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+			p, ok := byID[id]
+			if !ok {
+				return nil, errUnknownPeer
+			}
+
+			return p.getBlocks(from)
+		},
+	}
+}
+
+// createLinkedBlocks creates a chain of blocks on top of the parent with hashes computed,
+// so that the blocks pass continuity checks
+func createLinkedBlocks(parent *types.Header, num int) []*types.Block {
+	blocks := make([]*types.Block, num)
+
+	for i := 0; i < num; i++ {
+		header := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     parent.Number + 1,
+		}
+
+		blocks[i] = &types.Block{Header: header.ComputeHash()}
+		parent = header
+	}
+
+	return blocks
+}
+
+func Test_bulkSyncWithPeer_FakePeer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genesis     = (&types.Header{Number: 0}).ComputeHash()
+		chain       = createLinkedBlocks(genesis, 20)
+		errNoStream = errors.New("no stream")
+	)
+
+	tests := []struct {
+		name          string
+		peer          *fakeSyncPeer
+		expectedPhase SyncPhase
+		expectedErr   error
+		written       int
+	}{
+		{
+			name:    "should sync all blocks from honest peer",
+			peer:    &fakeSyncPeer{id: peer.ID("A"), blocks: chain, latency: time.Millisecond},
+			written: len(chain),
+		},
+		{
+			name:          "should fail to start with peer failing blocks request",
+			peer:          &fakeSyncPeer{id: peer.ID("A"), blocks: chain, getBlocksErr: errNoStream},
+			expectedPhase: SyncPhaseStart,
+			expectedErr:   errNoStream,
+		},
+		{
+			name: "should reject blocks of peer serving another chain",
+			peer: &fakeSyncPeer{
+				id:     peer.ID("A"),
+				blocks: createLinkedBlocks((&types.Header{Number: 0, ExtraData: []byte{1}}).ComputeHash(), 20),
+			},
+			expectedPhase: SyncPhaseDownload,
+			expectedErr:   errBlockNotContiguous,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			written := 0
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{
+					headerHandler: func() *types.Header {
+						return genesis
+					},
+					verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+						return &types.FullBlock{Block: b}, nil
+					},
+					writeFullBlockHandler: func(*types.FullBlock) error {
+						written++
+
+						return nil
+					},
+				},
+				time.Second,
+				newFakeSyncPeerClient(test.peer),
+				&mockProgression{},
+			)
+
+			status, err := test.peer.status()
+			assert.NoError(t, err)
+
+			_, err = syncer.bulkSyncWithPeer(context.Background(), test.peer.id, status.Number,
+				func(*types.FullBlock) bool { return false })
+
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				var syncErr *SyncError
+
+				if assert.ErrorAs(t, err, &syncErr) {
+					assert.Equal(t, test.expectedPhase, syncErr.Phase)
+				}
+
+				assert.ErrorIs(t, err, test.expectedErr)
+			}
+
+			assert.Equal(t, test.written, written)
+		})
+	}
+}
+
+func TestSync_FakeLyingPeer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		verifyLatestBlock bool
+		// failures the liar is blamed for
+		liarFailures uint64
+	}{
+		{
+			// the liar is caught when its latest block is verified and blamed for it
+			name:              "should skip the liar once its latest block is verified",
+			verifyLatestBlock: true,
+			liarFailures:      1,
+		},
+		{
+			// the liar serves the blocks it has and is skipped once its session ends short
+			name:              "should skip the liar once its session ends short",
+			verifyLatestBlock: false,
+			liarFailures:      0,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				genesis = (&types.Header{Number: 0}).ComputeHash()
+				chain   = createLinkedBlocks(genesis, 20)
+
+				// peer A claims a far higher block than it has
+				liar   = &fakeSyncPeer{id: peer.ID("A"), blocks: chain[:10], advertisedNumber: 100}
+				honest = &fakeSyncPeer{id: peer.ID("B"), blocks: chain}
+
+				lock   sync.Mutex
+				latest = genesis
+
+				syncer = NewTestSyncer(
+					nil,
+					&mockBlockchain{
+						headerHandler: func() *types.Header {
+							lock.Lock()
+							defer lock.Unlock()
+
+							return latest
+						},
+						verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+							return &types.FullBlock{Block: b}, nil
+						},
+						writeFullBlockHandler: func(b *types.FullBlock) error {
+							lock.Lock()
+							defer lock.Unlock()
+
+							latest = b.Block.Header
+
+							return nil
+						},
+					},
+					time.Second,
+					newFakeSyncPeerClient(liar, honest),
+					&mockProgression{},
+				)
+			)
+
+			WithPeerLatestBlockVerification(test.verifyLatestBlock)(syncer)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			syncer.initializePeerMap()
+
+			// keep notifying of statuses as the peers keep gossiping them
+			go func() {
+				for ctx.Err() == nil {
+					syncer.notifyNewStatusEvent()
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+
+			assert.NoError(t, syncer.SyncToTarget(ctx, uint64(len(chain))))
+
+			lock.Lock()
+			assert.Equal(t, chain[len(chain)-1].Header, latest)
+			lock.Unlock()
+
+			assert.Equal(t, test.liarFailures, syncer.peerStats.get(liar.id).Failures())
+			assert.Zero(t, syncer.peerStats.get(honest.id).Failures())
+
+			_, liarGetBlocksCalls := liar.calls()
+			assert.Equal(t, 1, liarGetBlocksCalls)
+		})
+	}
+}
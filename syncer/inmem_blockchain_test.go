@@ -0,0 +1,269 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/blockchain"
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// inMemoryEventBufferSize is the number of events buffered per subscription,
+	// events are dropped for a subscription whose buffer is full
+	inMemoryEventBufferSize = 256
+)
+
+var errBlockNotChild = errors.New("block is not a child of the head")
+
+// inMemorySubscription is a blockchain subscription with a buffered event channel
+type inMemorySubscription struct {
+	eventCh chan *blockchain.Event
+}
+
+func (s *inMemorySubscription) GetEventCh() chan *blockchain.Event {
+	return s.eventCh
+}
+
+func (s *inMemorySubscription) GetEvent() *blockchain.Event {
+	return <-s.eventCh
+}
+
+// inMemoryBlockchain is a blockchain keeping its blocks in memory, it publishes a head event
+// for each written block and counts the calls, so that tests can preload a chain,
+// follow the events and assert what got written
+type inMemoryBlockchain struct {
+	lock sync.Mutex
+
+	// blocks of the chain, the first one is genesis
+	blocks        []*types.Block
+	subscriptions map[*inMemorySubscription]struct{}
+
+	// verifyErr returns the error of verifying the block if set
+	verifyErr func(*types.Block) error
+
+	verifyCalls int
+	writeCalls  int
+}
+
+// newInMemoryBlockchain returns a blockchain preloaded with the genesis block and the given blocks
+func newInMemoryBlockchain(genesis *types.Header, blocks ...*types.Block) *inMemoryBlockchain {
+	return &inMemoryBlockchain{
+		blocks:        append([]*types.Block{{Header: genesis}}, blocks...),
+		subscriptions: make(map[*inMemorySubscription]struct{}),
+	}
+}
+
+func (b *inMemoryBlockchain) SubscribeEvents() blockchain.Subscription {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	sub := &inMemorySubscription{eventCh: make(chan *blockchain.Event, inMemoryEventBufferSize)}
+	b.subscriptions[sub] = struct{}{}
+
+	return sub
+}
+
+func (b *inMemoryBlockchain) UnsubscribeEvents(sub blockchain.Subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if s, ok := sub.(*inMemorySubscription); ok {
+		delete(b.subscriptions, s)
+	}
+}
+
+func (b *inMemoryBlockchain) Header() *types.Header {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.blocks[len(b.blocks)-1].Header
+}
+
+func (b *inMemoryBlockchain) GetBlockByNumber(number uint64, _ bool) (*types.Block, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if number >= uint64(len(b.blocks)) {
+		return nil, false
+	}
+
+	return b.blocks[number], true
+}
+
+func (b *inMemoryBlockchain) VerifyFinalizedBlock(block *types.Block) (*types.FullBlock, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.verifyCalls++
+
+	if b.verifyErr != nil {
+		if err := b.verifyErr(block); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.checkChild(block); err != nil {
+		return nil, err
+	}
+
+	return &types.FullBlock{Block: block}, nil
+}
+
+func (b *inMemoryBlockchain) WriteBlock(block *types.Block, source string) error {
+	return b.WriteFullBlock(&types.FullBlock{Block: block}, source)
+}
+
+func (b *inMemoryBlockchain) WriteFullBlock(fullBlock *types.FullBlock, source string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.writeCalls++
+
+	if err := b.checkChild(fullBlock.Block); err != nil {
+		return err
+	}
+
+	b.blocks = append(b.blocks, fullBlock.Block)
+
+	event := &blockchain.Event{
+		NewChain: []*types.Header{fullBlock.Block.Header},
+		Type:     blockchain.EventHead,
+		Source:   source,
+	}
+
+	for sub := range b.subscriptions {
+		select {
+		case sub.eventCh <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// checkChild checks the block is the child of the head, the lock must be held
+func (b *inMemoryBlockchain) checkChild(block *types.Block) error {
+	head := b.blocks[len(b.blocks)-1].Header
+
+	if block.Number() != head.Number+1 || block.ParentHash() != head.Hash {
+		return fmt.Errorf("%w: block %d, head %d", errBlockNotChild, block.Number(), head.Number)
+	}
+
+	return nil
+}
+
+// writtenBlocks returns the blocks of the chain after genesis
+func (b *inMemoryBlockchain) writtenBlocks() []*types.Block {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	blocks := make([]*types.Block, len(b.blocks)-1)
+	copy(blocks, b.blocks[1:])
+
+	return blocks
+}
+
+// calls returns the number of verify and write calls
+func (b *inMemoryBlockchain) calls() (int, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.verifyCalls, b.writeCalls
+}
+
+func Test_inMemoryBlockchain(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genesis = (&types.Header{Number: 0}).ComputeHash()
+		blocks  = createLinkedBlocks(genesis, 5)
+		chain   = newInMemoryBlockchain(genesis, blocks[:2]...)
+	)
+
+	assert.Equal(t, uint64(2), chain.Header().Number)
+
+	block, ok := chain.GetBlockByNumber(1, true)
+	assert.True(t, ok)
+	assert.Equal(t, blocks[0], block)
+
+	_, ok = chain.GetBlockByNumber(3, true)
+	assert.False(t, ok)
+
+	sub := chain.SubscribeEvents()
+
+	// gapped block is rejected
+	assert.ErrorIs(t, chain.WriteBlock(blocks[3], "test"), errBlockNotChild)
+
+	assert.NoError(t, chain.WriteBlock(blocks[2], "test"))
+
+	event := sub.GetEvent()
+	assert.Equal(t, blockchain.EventHead, event.Type)
+	assert.Equal(t, []*types.Header{blocks[2].Header}, event.NewChain)
+
+	chain.UnsubscribeEvents(sub)
+
+	assert.NoError(t, chain.WriteBlock(blocks[3], "test"))
+	assert.Empty(t, sub.GetEventCh())
+
+	assert.Equal(t, blocks[:4], chain.writtenBlocks())
+
+	_, writeCalls := chain.calls()
+	assert.Equal(t, 3, writeCalls)
+}
+
+func Test_bulkSyncWithPeer_InMemoryBlockchain(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genesis        = (&types.Header{Number: 0}).ComputeHash()
+		blocks         = createLinkedBlocks(genesis, 20)
+		errInvalidSeal = errors.New("invalid seal")
+		chain          = newInMemoryBlockchain(genesis)
+
+		syncer = NewTestSyncer(
+			nil,
+			chain,
+			time.Second,
+			newFakeSyncPeerClient(&fakeSyncPeer{id: peer.ID("A"), blocks: blocks}),
+			&mockProgression{},
+		)
+	)
+
+	chain.verifyErr = func(b *types.Block) error {
+		if b.Number() == 16 {
+			return errInvalidSeal
+		}
+
+		return nil
+	}
+
+	sub := chain.SubscribeEvents()
+	defer chain.UnsubscribeEvents(sub)
+
+	result, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("A"), uint64(len(blocks)),
+		func(*types.FullBlock) bool { return false })
+
+	assert.ErrorIs(t, err, errInvalidSeal)
+	assert.Equal(t, uint64(15), result.EndHeight)
+	assert.Equal(t, blocks[:15], chain.writtenBlocks())
+
+	// a head event was published for every written block
+	for _, b := range blocks[:15] {
+		event := sub.GetEvent()
+
+		assert.Equal(t, b.Number(), event.NewChain[0].Number)
+	}
+
+	assert.Empty(t, sub.GetEventCh())
+
+	verifyCalls, writeCalls := chain.calls()
+	assert.Equal(t, 16, verifyCalls)
+	assert.Equal(t, 15, writeCalls)
+}
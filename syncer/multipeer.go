@@ -0,0 +1,544 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	defaultSegmentSize = 100
+)
+
+var (
+	errNoSyncPeers       = errors.New("no peer has the blocks to sync")
+	errSegmentIncomplete = errors.New("peer stopped streaming before the end of segment")
+	errSegmentNoPeer     = errors.New("no peer left to download segment from")
+	errSegmentTimeout    = errors.New("timeout downloading segment from peer")
+)
+
+// segment is a range of blocks downloaded from a single peer
+type segment struct {
+	from uint64
+	to   uint64
+
+	// peers that failed to serve the segment
+	failedPeers map[peer.ID]bool
+}
+
+// segmentResult is the outcome of downloading a segment
+type segmentResult struct {
+	segment *segment
+	peerID  peer.ID
+	// session of the segment, it lasts until the segment is written or fails
+	session *SyncSession
+	blocks  []*types.Block
+	elapsed time.Duration
+	err     error
+}
+
+// splitIntoSegments splits the range of blocks from and to inclusive into segments of the given size
+func splitIntoSegments(from, to, size uint64) []*segment {
+	if size == 0 {
+		size = defaultSegmentSize
+	}
+
+	segments := make([]*segment, 0, (to-from)/size+1)
+
+	for {
+		seg := newSegment(from, to, size)
+		segments = append(segments, seg)
+
+		if seg.to == to {
+			break
+		}
+
+		from = seg.to + 1
+	}
+
+	return segments
+}
+
+// newSegment returns the segment of the given size starting at block from, cut at block last
+func newSegment(from, last, size uint64) *segment {
+	to := from + size - 1
+	if to > last || to < from {
+		to = last
+	}
+
+	return &segment{
+		from:        from,
+		to:          to,
+		failedPeers: make(map[peer.ID]bool),
+	}
+}
+
+// segmentSizer adapts the size of new segments to the download throughput, the size grows
+// by the minimum size while the throughput doesn't drop and is halved once a download fails.
+// The size is fixed if the minimum and maximum sizes are equal
+type segmentSizer struct {
+	size    uint64
+	minSize uint64
+	maxSize uint64
+
+	// blocks per second of the last downloaded segment
+	lastThroughput float64
+}
+
+// newSegmentSizer returns the segment sizer starting with the given size,
+// zero minimum or maximum size disables adapting the size
+func newSegmentSizer(size, minSize, maxSize uint64) *segmentSizer {
+	if size == 0 {
+		size = defaultSegmentSize
+	}
+
+	if minSize == 0 || maxSize == 0 {
+		minSize, maxSize = size, size
+	}
+
+	if size < minSize {
+		size = minSize
+	}
+
+	if size > maxSize {
+		size = maxSize
+	}
+
+	return &segmentSizer{
+		size:    size,
+		minSize: minSize,
+		maxSize: maxSize,
+	}
+}
+
+// onSuccess grows the size if the throughput of the downloaded segment didn't drop
+func (z *segmentSizer) onSuccess(blocks uint64, elapsed time.Duration) {
+	if blocks == 0 || elapsed <= 0 {
+		return
+	}
+
+	throughput := float64(blocks) / elapsed.Seconds()
+
+	if throughput >= z.lastThroughput {
+		z.size += z.minSize
+		if z.size > z.maxSize {
+			z.size = z.maxSize
+		}
+	}
+
+	z.lastThroughput = throughput
+}
+
+// onFailure halves the size after a failed download and measures throughput from scratch
+func (z *segmentSizer) onFailure() {
+	z.size /= 2
+	if z.size < z.minSize {
+		z.size = z.minSize
+	}
+
+	z.lastThroughput = 0
+}
+
+// MultiPeerSync syncs blocks up to target height by splitting the range into segments
+// and downloading them from several peers concurrently. Segments are written in order,
+// a segment a peer fails to serve is reassigned to another peer
+func (s *syncer) MultiPeerSync(ctx context.Context, target uint64) error {
+	localHeader := s.blockchain.Header()
+	if localHeader.Number >= target {
+		return nil
+	}
+
+	peers := s.segmentPeers(localHeader.Number)
+	if len(peers) == 0 {
+		return errNoSyncPeers
+	}
+
+	// the whole multi peer sync counts as a single sync session, its downloads are bounded
+	// by the number of peers
+	if err := s.acquireSyncSession(ctx); err != nil {
+		return err
+	}
+
+	defer s.releaseSyncSession()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		pending  = make([]*segment, 0)
+		total    = 0
+		sizer    = newSegmentSizer(s.segmentSize, s.minSegmentSize, s.maxSegmentSize)
+		idle     = make(map[peer.ID]*NoForkPeer, len(peers))
+		buffered = make(map[uint64]*segmentResult)
+		resultCh = make(chan *segmentResult, len(peers))
+
+		// number of the next block to write and hash of its parent
+		nextNumber = localHeader.Number + 1
+		parentHash = localHeader.Hash
+
+		// number of the first block not in any segment yet, segments are cut as they're needed
+		// so that their size follows the throughput
+		nextUncut = localHeader.Number + 1
+
+		// segments downloaded or being downloaded ahead of the next one to write are
+		// bounded, so that a slow peer doesn't make the others fill up the memory
+		maxAhead   = 2 * len(peers)
+		inProgress = 0
+
+		// blocks of the segments downloaded or being downloaded but not written yet
+		inFlightBlocks uint64
+	)
+
+	for _, p := range peers {
+		idle[p.ID] = p
+	}
+
+	s.startProgression(nextNumber, target)
+
+	defer func() {
+		// wait for running downloads so that nothing uses the streams after return
+		cancel()
+
+		for ; inProgress > 0; inProgress-- {
+			result := <-resultCh
+			s.sessions.finish(result.session)
+		}
+
+		for _, result := range buffered {
+			s.sessions.finish(result.session)
+		}
+
+		s.stopProgression()
+	}()
+
+	for nextNumber <= target {
+		// assign pending segments to idle peers, the next segment to write is always assigned
+		for i := 0; len(idle) > 0; {
+			cut := false
+
+			// cut a new segment once all the pending ones are checked
+			if i == len(pending) {
+				if nextUncut > target || inProgress+len(buffered)+len(pending) >= maxAhead {
+					break
+				}
+
+				seg := newSegment(nextUncut, target, sizer.size)
+				if seg.from != nextNumber && s.exceedsMaxBlocksInFlight(inFlightBlocks, seg) {
+					break
+				}
+
+				pending = append(pending, seg)
+				cut = true
+			}
+
+			seg := pending[i]
+
+			// skip the segments ahead over the limits, the next segment to write may be further in pending
+			if seg.from != nextNumber && (inProgress+len(buffered) >= maxAhead ||
+				s.exceedsMaxBlocksInFlight(inFlightBlocks, seg)) {
+				i++
+
+				continue
+			}
+
+			p, err := pickSegmentPeer(seg, peers, idle)
+			if err != nil {
+				return err
+			}
+
+			if p == nil {
+				// leave the rest uncut until a peer is free, it's cut in the size of that time
+				if cut {
+					pending = pending[:i]
+
+					break
+				}
+
+				i++
+
+				continue
+			}
+
+			if cut {
+				nextUncut = seg.to + 1
+				total++
+			}
+
+			delete(idle, p.ID)
+			pending = append(pending[:i], pending[i+1:]...)
+			inProgress++
+			inFlightBlocks += seg.to - seg.from + 1
+
+			// every segment is a session with its peer, so that it's listed and can be canceled
+			session, sessionCtx := s.sessions.start(ctx, p.ID, seg.from, seg.to)
+
+			go func() {
+				start := time.Now()
+				blocks, err := s.downloadSegment(sessionCtx, p.ID, seg)
+
+				resultCh <- &segmentResult{
+					segment: seg,
+					peerID:  p.ID,
+					session: session,
+					blocks:  blocks,
+					elapsed: time.Since(start),
+					err:     err,
+				}
+			}()
+		}
+
+		var result *segmentResult
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result = <-resultCh:
+		}
+
+		inProgress--
+		idle[result.peerID] = findPeer(peers, result.peerID)
+
+		s.tracer.record(TraceRecord{
+			Kind:   TraceSegmentFinished,
+			PeerID: result.peerID,
+			From:   result.segment.from,
+			To:     result.segment.to,
+			Err:    result.err,
+		})
+
+		if result.err != nil {
+			s.sessions.finish(result.session)
+
+			inFlightBlocks -= result.segment.to - result.segment.from + 1
+
+			s.logger.Warn("failed to download segment, reassign it", "peer", result.peerID,
+				"from", result.segment.from, "to", result.segment.to, "err", result.err)
+
+			if isPeerFault(result.err) {
+				sizer.onFailure()
+			}
+
+			s.failSegment(result, &pending)
+
+			continue
+		}
+
+		sizer.onSuccess(uint64(len(result.blocks)), result.elapsed)
+
+		buffered[result.segment.from] = result
+
+		// write the downloaded segments which are next in order
+		for {
+			next, ok := buffered[nextNumber]
+			if !ok {
+				break
+			}
+
+			delete(buffered, nextNumber)
+
+			inFlightBlocks -= uint64(len(next.blocks))
+
+			written, err := s.writeSegment(ctx, next, parentHash)
+
+			s.sessions.finish(next.session)
+
+			if written > 0 {
+				lastBlock := next.blocks[written-1]
+
+				nextNumber = lastBlock.Number() + 1
+				parentHash = lastBlock.Hash()
+			}
+
+			if err == nil {
+				s.peerStats.get(next.peerID).resetSyncFailures()
+
+				continue
+			}
+
+			if !isPeerFault(err) {
+				return err
+			}
+
+			s.logger.Warn("peer served invalid segment, reassign it", "peer", next.peerID,
+				"from", next.segment.from, "to", next.segment.to, "err", err)
+
+			// download the rest of the segment again from another peer
+			next.segment.from = nextNumber
+			next.err = err
+			s.failSegment(next, &pending)
+
+			break
+		}
+	}
+
+	s.logger.Info("multi peer sync finished", "segments", total, "peers", len(peers), "target", target)
+
+	return nil
+}
+
+// exceedsMaxBlocksInFlight returns whether downloading the segment would make the number of
+// downloaded but not written blocks exceed the limit
+func (s *syncer) exceedsMaxBlocksInFlight(inFlightBlocks uint64, seg *segment) bool {
+	return s.maxBlocksInFlight > 0 && inFlightBlocks+seg.to-seg.from+1 > s.maxBlocksInFlight
+}
+
+// segmentPeers returns the peers ahead of local latest block which are accepted and not in backoff,
+// best first
+func (s *syncer) segmentPeers(localLatest uint64) []*NoForkPeer {
+	var (
+		peers = make([]*NoForkPeer, 0)
+		now   = time.Now()
+	)
+
+	s.peerMap.Range(func(key, value interface{}) bool {
+		p, _ := value.(*NoForkPeer)
+
+		// leave out the peers Sync wouldn't select either
+		if p.Number > localLatest && !s.peerStats.get(p.ID).inBackoff(now) && s.isPeerAccepted(p.ID) {
+			peers = append(peers, p)
+		}
+
+		return true
+	})
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].IsBetter(peers[j])
+	})
+
+	return peers
+}
+
+// pickSegmentPeer returns an idle peer which has all the blocks of the segment and hasn't failed it,
+// nil if every such peer is busy, error if there is no such peer at all
+func pickSegmentPeer(seg *segment, peers []*NoForkPeer, idle map[peer.ID]*NoForkPeer) (*NoForkPeer, error) {
+	hasCandidate := false
+
+	for _, p := range peers {
+		if p.Number < seg.to || seg.failedPeers[p.ID] {
+			continue
+		}
+
+		hasCandidate = true
+
+		if _, ok := idle[p.ID]; ok {
+			return p, nil
+		}
+	}
+
+	if !hasCandidate {
+		return nil, fmt.Errorf("%w: blocks %d to %d", errSegmentNoPeer, seg.from, seg.to)
+	}
+
+	return nil, nil
+}
+
+// findPeer returns the peer with the given ID from the list
+func findPeer(peers []*NoForkPeer, peerID peer.ID) *NoForkPeer {
+	for _, p := range peers {
+		if p.ID == peerID {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// failSegment attributes the failure to the peer and puts the segment back to the front of pending ones
+func (s *syncer) failSegment(result *segmentResult, pending *[]*segment) {
+	if isPeerFault(result.err) {
+		s.addSyncFailure(result.peerID)
+	}
+
+	result.segment.failedPeers[result.peerID] = true
+
+	*pending = append([]*segment{result.segment}, *pending...)
+}
+
+// downloadSegment downloads all the blocks of the segment from the peer
+func (s *syncer) downloadSegment(ctx context.Context, peerID peer.ID, seg *segment) ([]*types.Block, error) {
+	// stop the stream once the last block of the segment is received or the segment times out
+	var (
+		segmentCtx context.Context
+		cancel     context.CancelFunc
+	)
+
+	if s.segmentTimeout > 0 {
+		segmentCtx, cancel = context.WithTimeout(ctx, s.segmentTimeout)
+	} else {
+		segmentCtx, cancel = context.WithCancel(ctx)
+	}
+
+	defer cancel()
+
+	blockCh, err := s.syncPeerClient.GetBlocks(segmentCtx, peerID, seg.from, s.blockTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := s.syncPeerClient.CloseStream(peerID); err != nil {
+			s.logger.Error("Failed to close stream: ", err)
+		}
+	}()
+
+	blocks := make([]*types.Block, 0, seg.to-seg.from+1)
+
+	for number := seg.from; number <= seg.to; {
+		select {
+		case block, ok := <-blockCh:
+			if !ok {
+				return nil, fmt.Errorf("%w: expected block %d", errSegmentIncomplete, number)
+			}
+
+			if block.Number() != number {
+				return nil, fmt.Errorf("%w: expected block number %d, got %d",
+					errBlockNotContiguous, number, block.Number())
+			}
+
+			blocks = append(blocks, block)
+			number++
+		case <-segmentCtx.Done():
+			// the peer is blamed only if the segment timed out, not if the sync was canceled
+			if ctx.Err() == nil && errors.Is(segmentCtx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: blocks %d to %d, received %d",
+					errSegmentTimeout, seg.from, seg.to, len(blocks))
+			}
+
+			return nil, ctx.Err()
+		case <-time.After(s.blockTimeout):
+			// the block is held back by our own download limit, not by the peer
+			if s.syncPeerClient.IsThrottled(blockCh) {
+				continue
+			}
+
+			return nil, errTimeout
+		}
+	}
+
+	return blocks, nil
+}
+
+// writeSegment verifies and writes the blocks of the downloaded segment,
+// returns the number of written blocks
+func (s *syncer) writeSegment(ctx context.Context, result *segmentResult, parentHash types.Hash) (int, error) {
+	for i, block := range result.blocks {
+		if err := checkBlockContinuity(block, result.segment.from+uint64(i), parentHash); err != nil {
+			return i, err
+		}
+
+		if _, err := s.importBlock(ctx, result.peerID, block); err != nil {
+			return i, err
+		}
+
+		result.session.blocksWritten.Add(1)
+
+		parentHash = block.Hash()
+	}
+
+	return len(result.blocks), nil
+}
@@ -0,0 +1,605 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/blockchain"
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitIntoSegments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		from     uint64
+		to       uint64
+		size     uint64
+		expected [][2]uint64
+	}{
+		{
+			name:     "should split range evenly",
+			from:     1,
+			to:       300,
+			size:     100,
+			expected: [][2]uint64{{1, 100}, {101, 200}, {201, 300}},
+		},
+		{
+			name:     "should put the rest into the last segment",
+			from:     11,
+			to:       35,
+			size:     10,
+			expected: [][2]uint64{{11, 20}, {21, 30}, {31, 35}},
+		},
+		{
+			name:     "should return single segment for short range",
+			from:     5,
+			to:       5,
+			size:     10,
+			expected: [][2]uint64{{5, 5}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			segments := splitIntoSegments(test.from, test.to, test.size)
+
+			ranges := make([][2]uint64, 0, len(segments))
+			for _, seg := range segments {
+				ranges = append(ranges, [2]uint64{seg.from, seg.to})
+			}
+
+			assert.Equal(t, test.expected, ranges)
+		})
+	}
+}
+
+func TestMultiPeerSync(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks        = createMockBlocks(300)
+		errPeerBroken = errors.New("peer is broken")
+
+		lock          sync.Mutex
+		writtenBlocks = make([]*types.Block, 0, len(blocks))
+		requests      = make(map[peer.ID][]uint64)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					writtenBlocks = append(writtenBlocks, b.Block)
+
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					lock.Lock()
+					defer lock.Unlock()
+
+					requests[id] = append(requests[id], from)
+
+					// peer B fails every segment assigned to it
+					if id == peer.ID("B") {
+						return nil, errPeerBroken
+					}
+
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithSegmentSize(100)(syncer)
+
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("A"), Number: 300, Distance: big.NewInt(1)},
+		&NoForkPeer{ID: peer.ID("B"), Number: 300, Distance: big.NewInt(2)},
+		&NoForkPeer{ID: peer.ID("C"), Number: 300, Distance: big.NewInt(3)},
+	)
+
+	assert.NoError(t, syncer.MultiPeerSync(context.Background(), 300))
+
+	assert.Equal(t, blocks, writtenBlocks)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// segment of peer B was reassigned to another peer
+	assert.NotEmpty(t, requests[peer.ID("B")])
+	assert.Equal(t, 3, len(requests[peer.ID("A")])+len(requests[peer.ID("C")]))
+	assert.Equal(t, uint64(len(requests[peer.ID("B")])), syncer.peerStats.get(peer.ID("B")).Failures())
+}
+
+func TestMultiPeerSync_NoPeerLeft(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks = createMockBlocks(20)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(*types.FullBlock) error {
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					// the peer stops streaming before the end of segment
+					return blocksToCh(blocks[from-1:from+4], 0), nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithSegmentSize(10)(syncer)
+
+	assert.ErrorIs(t, syncer.MultiPeerSync(context.Background(), 20), errNoSyncPeers)
+
+	syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("A"), Number: 20, Distance: big.NewInt(1)})
+
+	assert.ErrorIs(t, syncer.MultiPeerSync(context.Background(), 20), errSegmentNoPeer)
+}
+
+func Test_segmentPeers(t *testing.T) {
+	t.Parallel()
+
+	syncer := NewTestSyncer(nil, &mockBlockchain{}, time.Second, &mockSyncPeerClient{}, &mockProgression{})
+
+	WithSyncBackoff(time.Minute, time.Hour)(syncer)
+	WithPeerAccessLists(nil, []peer.ID{peer.ID("C")})(syncer)
+
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("A"), Number: 20, Distance: big.NewInt(2)},
+		// in backoff
+		&NoForkPeer{ID: peer.ID("B"), Number: 20, Distance: big.NewInt(1)},
+		// denied
+		&NoForkPeer{ID: peer.ID("C"), Number: 20, Distance: big.NewInt(1)},
+		// disconnected
+		&NoForkPeer{ID: peer.ID("D"), Number: 20, Distance: big.NewInt(1)},
+		// behind
+		&NoForkPeer{ID: peer.ID("E"), Number: 10, Distance: big.NewInt(1)},
+		&NoForkPeer{ID: peer.ID("F"), Number: 30, Distance: big.NewInt(3)},
+	)
+
+	syncer.addSyncFailure(peer.ID("B"))
+	syncer.peerAccessList.disconnect(peer.ID("D"), time.Time{})
+
+	peers := syncer.segmentPeers(10)
+
+	ids := make([]peer.ID, 0, len(peers))
+	for _, p := range peers {
+		ids = append(ids, p.ID)
+	}
+
+	assert.Equal(t, []peer.ID{peer.ID("F"), peer.ID("A")}, ids)
+}
+
+func TestMultiPeerSync_Sessions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks    = createMockBlocks(20)
+		releaseCh = make(chan struct{})
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(*types.FullBlock) error {
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					ch := make(chan *types.Block)
+
+					// the segments are held until the sessions are checked
+					go func() {
+						defer close(ch)
+
+						<-releaseCh
+
+						for _, b := range blocks[from-1:] {
+							ch <- b
+						}
+					}()
+
+					return ch, nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithSegmentSize(10)(syncer)
+
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("A"), Number: 20, Distance: big.NewInt(1)},
+		&NoForkPeer{ID: peer.ID("B"), Number: 20, Distance: big.NewInt(2)},
+	)
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.MultiPeerSync(context.Background(), 20)
+	}()
+
+	// every segment being downloaded is a session with its peer
+	assert.Eventually(t, func() bool {
+		return len(syncer.SyncSessions()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	heights := make(map[peer.ID][2]uint64)
+	for _, session := range syncer.SyncSessions() {
+		heights[session.PeerID] = [2]uint64{session.StartHeight, session.TargetHeight}
+	}
+
+	assert.Equal(t, map[peer.ID][2]uint64{
+		peer.ID("A"): {1, 10},
+		peer.ID("B"): {11, 20},
+	}, heights)
+
+	close(releaseCh)
+
+	assert.NoError(t, <-errCh)
+	assert.Empty(t, syncer.SyncSessions())
+}
+
+func Test_downloadSegment_Timeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks = createMockBlocks(10)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					// the peer stalls after the first block of the segment
+					ch := make(chan *types.Block, 1)
+					ch <- blocks[from-1]
+
+					return ch, nil
+				},
+			},
+			&mockProgression{},
+		)
+
+		seg = &segment{from: 1, to: 10, failedPeers: make(map[peer.ID]bool)}
+	)
+
+	WithSegmentTimeout(50 * time.Millisecond)(syncer)
+
+	start := time.Now()
+
+	_, err := syncer.downloadSegment(context.Background(), peer.ID("A"), seg)
+
+	assert.ErrorIs(t, err, errSegmentTimeout)
+	assert.True(t, isPeerFault(err))
+	assert.Less(t, time.Since(start), time.Second)
+
+	// canceled sync isn't blamed on the peer
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err = syncer.downloadSegment(ctx, peer.ID("A"), seg)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, isPeerFault(err))
+}
+
+func TestMultiPeerSync_MaxBlocksInFlight(t *testing.T) {
+	t.Parallel()
+
+	const (
+		segmentSize       = 10
+		maxBlocksInFlight = 30
+	)
+
+	var (
+		blocks       = createMockBlocks(300)
+		latestNumber atomic.Uint64
+
+		lock           sync.Mutex
+		requestedFroms []uint64
+		maxInFlight    uint64
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					latestNumber.Store(b.Block.Number())
+
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					lock.Lock()
+					defer lock.Unlock()
+
+					requestedFroms = append(requestedFroms, from)
+
+					// blocks of the requested segments which aren't written yet
+					inFlight := uint64(0)
+					written := latestNumber.Load()
+
+					for _, f := range requestedFroms {
+						last := f + segmentSize - 1
+
+						switch {
+						case last <= written:
+						case f > written:
+							inFlight += segmentSize
+						default:
+							inFlight += last - written
+						}
+					}
+
+					if inFlight > maxInFlight {
+						maxInFlight = inFlight
+					}
+
+					delay := time.Duration(0)
+					if id == peer.ID("A") {
+						delay = time.Millisecond
+					}
+
+					return blocksToCh(blocks[from-1:], delay), nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithSegmentSize(segmentSize)(syncer)
+	WithMaxBlocksInFlight(maxBlocksInFlight)(syncer)
+
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("A"), Number: 300, Distance: big.NewInt(1)},
+		&NoForkPeer{ID: peer.ID("B"), Number: 300, Distance: big.NewInt(2)},
+		&NoForkPeer{ID: peer.ID("C"), Number: 300, Distance: big.NewInt(3)},
+	)
+
+	assert.NoError(t, syncer.MultiPeerSync(context.Background(), 300))
+	assert.Equal(t, uint64(300), latestNumber.Load())
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.Len(t, requestedFroms, 300/segmentSize)
+	assert.LessOrEqual(t, maxInFlight, uint64(maxBlocksInFlight))
+}
+
+func Test_segmentSizer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should keep size fixed when adapting is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		sizer := newSegmentSizer(50, 0, 0)
+
+		sizer.onSuccess(50, 10*time.Millisecond)
+		assert.Equal(t, uint64(50), sizer.size)
+
+		sizer.onFailure()
+		assert.Equal(t, uint64(50), sizer.size)
+	})
+
+	t.Run("should clamp initial size to bounds", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, uint64(40), newSegmentSizer(0, 10, 40).size)
+		assert.Equal(t, uint64(10), newSegmentSizer(5, 10, 40).size)
+	})
+
+	t.Run("should grow while throughput improves and back off on failure", func(t *testing.T) {
+		t.Parallel()
+
+		sizer := newSegmentSizer(10, 10, 40)
+
+		// latency per segment stays the same while segments grow
+		sizer.onSuccess(10, 100*time.Millisecond)
+		assert.Equal(t, uint64(20), sizer.size)
+
+		sizer.onSuccess(20, 100*time.Millisecond)
+		assert.Equal(t, uint64(30), sizer.size)
+
+		// latency rises, throughput drops
+		sizer.onSuccess(30, 300*time.Millisecond)
+		assert.Equal(t, uint64(30), sizer.size)
+
+		// latency recovers
+		sizer.onSuccess(30, 100*time.Millisecond)
+		assert.Equal(t, uint64(40), sizer.size)
+
+		sizer.onSuccess(40, 100*time.Millisecond)
+		assert.Equal(t, uint64(40), sizer.size)
+
+		// downloads time out
+		sizer.onFailure()
+		assert.Equal(t, uint64(20), sizer.size)
+
+		sizer.onFailure()
+		assert.Equal(t, uint64(10), sizer.size)
+
+		sizer.onFailure()
+		assert.Equal(t, uint64(10), sizer.size)
+	})
+}
+
+func TestMultiPeerSync_AdaptiveSegmentSize(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks       = createMockBlocks(200)
+		latestNumber atomic.Uint64
+
+		lock           sync.Mutex
+		requestedFroms []uint64
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					latestNumber.Store(b.Block.Number())
+
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					lock.Lock()
+					requestedFroms = append(requestedFroms, from)
+					lock.Unlock()
+
+					// the latency of a request doesn't depend on its size,
+					// so the bigger the segment the higher the throughput
+					time.Sleep(20 * time.Millisecond)
+
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithSegmentSize(10)(syncer)
+	WithAdaptiveSegmentSize(10, 50)(syncer)
+
+	syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("A"), Number: 200, Distance: big.NewInt(1)})
+
+	assert.NoError(t, syncer.MultiPeerSync(context.Background(), 200))
+	assert.Equal(t, uint64(200), latestNumber.Load())
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	sizes := make([]uint64, 0, len(requestedFroms))
+	for i := 1; i < len(requestedFroms); i++ {
+		sizes = append(sizes, requestedFroms[i]-requestedFroms[i-1])
+	}
+
+	if assert.GreaterOrEqual(t, len(sizes), 4) {
+		assert.Equal(t, []uint64{10, 20, 30, 40}, sizes[:4])
+	}
+
+	for _, size := range sizes {
+		assert.LessOrEqual(t, size, uint64(50))
+	}
+}
+
+func TestMultiPeerSync_DefaultsDownloadInParallel(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks = createMockBlocks(30)
+
+		active    atomic.Int32
+		maxActive atomic.Int32
+	)
+
+	syncer := NewSyncer(
+		hclog.NewNullLogger(),
+		&mockNetwork{selfID: peer.ID("self")},
+		&mockBlockchain{
+			subscription:  blockchain.NewMockSubscription(),
+			headerHandler: newSimpleHeaderHandler(0),
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(*types.FullBlock) error {
+				return nil
+			},
+		},
+		time.Second,
+		WithSegmentSize(5),
+	).(*syncer)
+
+	syncer.syncPeerClient = &mockSyncPeerClient{
+		getBlocksHandler: func(_ peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+			n := active.Add(1)
+
+			for {
+				if prev := maxActive.Load(); n <= prev || maxActive.CompareAndSwap(prev, n) {
+					break
+				}
+			}
+
+			blockCh := make(chan *types.Block)
+
+			go func() {
+				defer close(blockCh)
+				defer active.Add(-1)
+
+				for _, b := range blocks[from-1 : from+4] {
+					time.Sleep(10 * time.Millisecond)
+					blockCh <- b
+				}
+			}()
+
+			return blockCh, nil
+		},
+	}
+
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("A"), Number: 30, Distance: big.NewInt(1)},
+		&NoForkPeer{ID: peer.ID("B"), Number: 30, Distance: big.NewInt(2)},
+		&NoForkPeer{ID: peer.ID("C"), Number: 30, Distance: big.NewInt(3)},
+	)
+
+	assert.NoError(t, syncer.MultiPeerSync(context.Background(), 30))
+
+	// segments are downloaded concurrently even though a single sync session is allowed by default
+	assert.Greater(t, maxActive.Load(), int32(1))
+	assert.Zero(t, syncer.ActiveSyncSessions())
+}
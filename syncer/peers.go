@@ -1,12 +1,22 @@
 package syncer
 
 import (
+	"errors"
 	"math/big"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+var (
+	errPeerDenied       = errors.New("peer is in denylist")
+	errPeerNotAllowed   = errors.New("peer is not in allowlist")
+	errPeerIsSelf       = errors.New("peer is the local node")
+	errPeerDisconnected = errors.New("peer was disconnected manually")
+)
+
 type NoForkPeer struct {
 	// identifier
 	ID peer.ID
@@ -14,6 +24,8 @@ type NoForkPeer struct {
 	Number uint64
 	// peer's distance
 	Distance *big.Int
+	// features the peer advertised, see Supports
+	Capabilities []string
 }
 
 func (p *NoForkPeer) IsBetter(t *NoForkPeer) bool {
@@ -24,8 +36,33 @@ func (p *NoForkPeer) IsBetter(t *NoForkPeer) bool {
 	return p.Distance.Cmp(t.Distance) < 0
 }
 
+// Supports returns whether the peer advertised the feature, a peer which advertised
+// nothing runs a version without any of the features
+func (p *NoForkPeer) Supports(feature string) bool {
+	for _, c := range p.Capabilities {
+		if c == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SyncProgress is the sync progress a peer reports of itself
+type SyncProgress struct {
+	// peer's latest block number
+	Head uint64
+	// highest block number advertised by the peer's peers
+	Highest uint64
+	// whether the peer is syncing blocks
+	Syncing bool
+}
+
 type PeerMap struct {
 	sync.Map
+
+	// number of peers, kept by Put and Remove so that Len doesn't range over the map
+	count atomic.Int64
 }
 
 func NewPeerMap(peers []*NoForkPeer) *PeerMap {
@@ -38,13 +75,23 @@ func NewPeerMap(peers []*NoForkPeer) *PeerMap {
 
 func (m *PeerMap) Put(peers ...*NoForkPeer) {
 	for _, peer := range peers {
-		m.Store(peer.ID.String(), peer)
+		// a new status of a known peer replaces the old one
+		if _, loaded := m.Swap(peer.ID.String(), peer); !loaded {
+			m.count.Add(1)
+		}
 	}
 }
 
 // Remove removes a peer from heap if it exists
 func (m *PeerMap) Remove(peerID peer.ID) {
-	m.Delete(peerID.String())
+	if _, loaded := m.LoadAndDelete(peerID.String()); loaded {
+		m.count.Add(-1)
+	}
+}
+
+// Len returns the number of peers in the map
+func (m *PeerMap) Len() int {
+	return int(m.count.Load())
 }
 
 // BestPeer returns the top of heap
@@ -67,3 +114,121 @@ func (m *PeerMap) BestPeer(skipMap map[peer.ID]bool) *NoForkPeer {
 
 	return bestPeer
 }
+
+// WorstPeer returns the peer for which isWorse holds against every other peer
+func (m *PeerMap) WorstPeer(isWorse func(p, t *NoForkPeer) bool) *NoForkPeer {
+	var worstPeer *NoForkPeer
+
+	m.Range(func(key, value interface{}) bool {
+		peer, _ := value.(*NoForkPeer)
+
+		if worstPeer == nil || isWorse(peer, worstPeer) {
+			worstPeer = peer
+		}
+
+		return true
+	})
+
+	return worstPeer
+}
+
+// BestPeerByScore returns the peer with the highest score among the peers
+// whose latest block is above minNumber, ties are broken by IsBetter
+func (m *PeerMap) BestPeerByScore(
+	skipMap map[peer.ID]bool,
+	minNumber uint64,
+	score func(*NoForkPeer) float64,
+) *NoForkPeer {
+	var (
+		bestPeer  *NoForkPeer
+		bestScore float64
+	)
+
+	m.Range(func(key, value interface{}) bool {
+		peer, _ := value.(*NoForkPeer)
+
+		if skipMap != nil && skipMap[peer.ID] {
+			return true
+		}
+
+		// never pick a peer which doesn't have a new block
+		if peer.Number <= minNumber {
+			return true
+		}
+
+		peerScore := score(peer)
+
+		if bestPeer == nil || peerScore > bestScore || (peerScore == bestScore && peer.IsBetter(bestPeer)) {
+			bestPeer = peer
+			bestScore = peerScore
+		}
+
+		return true
+	})
+
+	return bestPeer
+}
+
+// peerAccessList decides which peers syncer may sync with
+type peerAccessList struct {
+	lock sync.RWMutex
+
+	// if not empty, only the peers in allowlist are accepted
+	allowlist map[peer.ID]struct{}
+	// peers in denylist are never accepted
+	denylist map[peer.ID]struct{}
+	// peers disconnected manually -> time until which they are not accepted, zero time means forever.
+	// They are kept apart from denylist so that replacing the lists doesn't let them back
+	disconnected map[peer.ID]time.Time
+}
+
+// set replaces allowlist and denylist
+func (l *peerAccessList) set(allowlist, denylist []peer.ID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.allowlist = toPeerSet(allowlist)
+	l.denylist = toPeerSet(denylist)
+}
+
+// disconnect rejects the peer until the given time, zero time rejects it forever
+func (l *peerAccessList) disconnect(peerID peer.ID, until time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.disconnected == nil {
+		l.disconnected = make(map[peer.ID]time.Time)
+	}
+
+	l.disconnected[peerID] = until
+}
+
+// check returns error with the reason if the peer is not accepted
+func (l *peerAccessList) check(peerID peer.ID) error {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if _, ok := l.denylist[peerID]; ok {
+		return errPeerDenied
+	}
+
+	if until, ok := l.disconnected[peerID]; ok && (until.IsZero() || time.Now().Before(until)) {
+		return errPeerDisconnected
+	}
+
+	if _, ok := l.allowlist[peerID]; len(l.allowlist) > 0 && !ok {
+		return errPeerNotAllowed
+	}
+
+	return nil
+}
+
+func toPeerSet(peerIDs []peer.ID) map[peer.ID]struct{} {
+	set := make(map[peer.ID]struct{}, len(peerIDs))
+
+	for _, id := range peerIDs {
+		set[id] = struct{}{}
+	}
+
+	return set
+}
@@ -3,6 +3,7 @@ package syncer
 import (
 	"math/big"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -113,6 +114,49 @@ func TestPutPeer(t *testing.T) {
 	)
 }
 
+func TestPeerMap_Len(t *testing.T) {
+	t.Parallel()
+
+	allPeers := getAllTestPeers()
+	peerMap := NewPeerMap(allPeers)
+
+	assert.Equal(t, len(allPeers), peerMap.Len())
+
+	// a new status of a known peer isn't counted again
+	peerMap.Put(&NoForkPeer{ID: allPeers[0].ID, Number: 100, Distance: big.NewInt(0)})
+	assert.Equal(t, len(allPeers), peerMap.Len())
+
+	peerMap.Remove(allPeers[0].ID)
+	assert.Equal(t, len(allPeers)-1, peerMap.Len())
+
+	// removing an unknown peer changes nothing
+	peerMap.Remove(allPeers[0].ID)
+	assert.Equal(t, len(allPeers)-1, peerMap.Len())
+
+	// concurrent updates of the same peers keep the count in line with the map
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				for _, p := range allPeers {
+					peerMap.Put(p)
+					peerMap.Remove(p.ID)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Zero(t, peerMap.Len())
+	assert.Empty(t, peerMapToPeers(peerMap))
+}
+
 func TestBestPeer(t *testing.T) {
 	t.Parallel()
 
@@ -160,3 +204,157 @@ func TestBestPeer(t *testing.T) {
 		})
 	}
 }
+
+func TestBestPeerByScore(t *testing.T) {
+	t.Parallel()
+
+	allPeers := getAllTestPeers()
+
+	// inverse of the latest block, the lowest peer has the highest score
+	score := func(p *NoForkPeer) float64 {
+		return 1 / float64(p.Number)
+	}
+
+	tests := []struct {
+		name      string
+		skipList  map[peer.ID]bool
+		minNumber uint64
+		peers     []*NoForkPeer
+		result    *NoForkPeer
+	}{
+		{
+			name:      "should return the peer with the highest score",
+			skipList:  nil,
+			minNumber: 0,
+			peers:     allPeers,
+			result:    allPeers[0],
+		},
+		{
+			name:      "should never return the peer whose latest block doesn't exceed min number",
+			skipList:  nil,
+			minNumber: allPeers[0].Number,
+			peers:     allPeers,
+			result:    allPeers[2], // tie with B is broken by distance
+		},
+		{
+			name: "should skip the peer in skip list",
+			skipList: map[peer.ID]bool{
+				allPeers[0].ID: true,
+			},
+			minNumber: 0,
+			peers:     allPeers,
+			result:    allPeers[2],
+		},
+		{
+			name:      "should return null if no peer exceeds min number",
+			skipList:  nil,
+			minNumber: allPeers[2].Number,
+			peers:     allPeers,
+			result:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			peerMap := NewPeerMap(test.peers)
+
+			assert.Equal(t, test.result, peerMap.BestPeerByScore(test.skipList, test.minNumber, score))
+		})
+	}
+}
+
+func TestWorstPeer(t *testing.T) {
+	t.Parallel()
+
+	allPeers := getAllTestPeers()
+
+	isWorse := func(p, t *NoForkPeer) bool {
+		return t.IsBetter(p)
+	}
+
+	assert.Equal(t, allPeers[0], NewPeerMap(allPeers).WorstPeer(isWorse))
+
+	// tie on latest block is broken by distance
+	assert.Equal(t, allPeers[1], NewPeerMap(allPeers[1:]).WorstPeer(isWorse))
+
+	assert.Nil(t, NewPeerMap(nil).WorstPeer(isWorse))
+}
+
+func TestNoForkPeer_Supports(t *testing.T) {
+	t.Parallel()
+
+	p := &NoForkPeer{ID: peer.ID("A"), Capabilities: []string{FeatureSyncProgress}}
+
+	assert.True(t, p.Supports(FeatureSyncProgress))
+	assert.False(t, p.Supports("unknown"))
+
+	// a peer of an older version advertises nothing
+	assert.False(t, (&NoForkPeer{ID: peer.ID("B")}).Supports(FeatureSyncProgress))
+}
+
+func Test_peerAccessList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		allowlist []peer.ID
+		denylist  []peer.ID
+		errs      map[peer.ID]error
+	}{
+		{
+			name:      "should accept all peers if both lists are empty",
+			allowlist: nil,
+			denylist:  nil,
+			errs: map[peer.ID]error{
+				peer.ID("A"): nil,
+				peer.ID("B"): nil,
+			},
+		},
+		{
+			name:      "should accept only the peers in allowlist",
+			allowlist: []peer.ID{peer.ID("A")},
+			denylist:  nil,
+			errs: map[peer.ID]error{
+				peer.ID("A"): nil,
+				peer.ID("B"): errPeerNotAllowed,
+			},
+		},
+		{
+			name:      "should reject the peers in denylist",
+			allowlist: nil,
+			denylist:  []peer.ID{peer.ID("A")},
+			errs: map[peer.ID]error{
+				peer.ID("A"): errPeerDenied,
+				peer.ID("B"): nil,
+			},
+		},
+		{
+			name:      "should prefer denylist over allowlist",
+			allowlist: []peer.ID{peer.ID("A"), peer.ID("B")},
+			denylist:  []peer.ID{peer.ID("A")},
+			errs: map[peer.ID]error{
+				peer.ID("A"): errPeerDenied,
+				peer.ID("B"): nil,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			accessList := new(peerAccessList)
+			accessList.set(test.allowlist, test.denylist)
+
+			for id, err := range test.errs {
+				assert.Equal(t, err, accessList.check(id), id)
+			}
+		})
+	}
+}
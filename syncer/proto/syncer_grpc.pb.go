@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.7
+// source: syncer/proto/syncer.proto
 
 package proto
 
@@ -12,6 +16,7 @@ import (
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
 const _ = grpc.SupportPackageIsVersion7
 
 // SyncPeerClient is the client API for SyncPeer service.
@@ -22,6 +27,10 @@ type SyncPeerClient interface {
 	GetBlocks(ctx context.Context, in *GetBlocksRequest, opts ...grpc.CallOption) (SyncPeer_GetBlocksClient, error)
 	// Returns server's status
 	GetStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SyncPeerStatus, error)
+	// Returns server's own sync progress
+	GetSyncProgress(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SyncProgress, error)
+	// Returns nothing, used to check liveness and round-trip time
+	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type syncPeerClient struct {
@@ -33,7 +42,7 @@ func NewSyncPeerClient(cc grpc.ClientConnInterface) SyncPeerClient {
 }
 
 func (c *syncPeerClient) GetBlocks(ctx context.Context, in *GetBlocksRequest, opts ...grpc.CallOption) (SyncPeer_GetBlocksClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_SyncPeer_serviceDesc.Streams[0], "/v1.SyncPeer/GetBlocks", opts...)
+	stream, err := c.cc.NewStream(ctx, &SyncPeer_ServiceDesc.Streams[0], "/v1.SyncPeer/GetBlocks", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +82,24 @@ func (c *syncPeerClient) GetStatus(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *syncPeerClient) GetSyncProgress(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SyncProgress, error) {
+	out := new(SyncProgress)
+	err := c.cc.Invoke(ctx, "/v1.SyncPeer/GetSyncProgress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *syncPeerClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/v1.SyncPeer/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SyncPeerServer is the server API for SyncPeer service.
 // All implementations must embed UnimplementedSyncPeerServer
 // for forward compatibility
@@ -81,6 +108,10 @@ type SyncPeerServer interface {
 	GetBlocks(*GetBlocksRequest, SyncPeer_GetBlocksServer) error
 	// Returns server's status
 	GetStatus(context.Context, *emptypb.Empty) (*SyncPeerStatus, error)
+	// Returns server's own sync progress
+	GetSyncProgress(context.Context, *emptypb.Empty) (*SyncProgress, error)
+	// Returns nothing, used to check liveness and round-trip time
+	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	mustEmbedUnimplementedSyncPeerServer()
 }
 
@@ -94,6 +125,12 @@ func (UnimplementedSyncPeerServer) GetBlocks(*GetBlocksRequest, SyncPeer_GetBloc
 func (UnimplementedSyncPeerServer) GetStatus(context.Context, *emptypb.Empty) (*SyncPeerStatus, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
 }
+func (UnimplementedSyncPeerServer) GetSyncProgress(context.Context, *emptypb.Empty) (*SyncProgress, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSyncProgress not implemented")
+}
+func (UnimplementedSyncPeerServer) Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
 func (UnimplementedSyncPeerServer) mustEmbedUnimplementedSyncPeerServer() {}
 
 // UnsafeSyncPeerServer may be embedded to opt out of forward compatibility for this service.
@@ -104,7 +141,7 @@ type UnsafeSyncPeerServer interface {
 }
 
 func RegisterSyncPeerServer(s grpc.ServiceRegistrar, srv SyncPeerServer) {
-	s.RegisterService(&_SyncPeer_serviceDesc, srv)
+	s.RegisterService(&SyncPeer_ServiceDesc, srv)
 }
 
 func _SyncPeer_GetBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
@@ -146,7 +183,46 @@ func _SyncPeer_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
-var _SyncPeer_serviceDesc = grpc.ServiceDesc{
+func _SyncPeer_GetSyncProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncPeerServer).GetSyncProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.SyncPeer/GetSyncProgress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncPeerServer).GetSyncProgress(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SyncPeer_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncPeerServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.SyncPeer/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncPeerServer).Ping(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SyncPeer_ServiceDesc is the grpc.ServiceDesc for SyncPeer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SyncPeer_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "v1.SyncPeer",
 	HandlerType: (*SyncPeerServer)(nil),
 	Methods: []grpc.MethodDesc{
@@ -154,6 +230,14 @@ var _SyncPeer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetStatus",
 			Handler:    _SyncPeer_GetStatus_Handler,
 		},
+		{
+			MethodName: "GetSyncProgress",
+			Handler:    _SyncPeer_GetSyncProgress_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _SyncPeer_Ping_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -3,12 +3,20 @@ package syncer
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/0xPolygon/polygon-edge/network/grpc"
 	"github.com/0xPolygon/polygon-edge/syncer/proto"
 	"github.com/0xPolygon/polygon-edge/types"
 	"github.com/armon/go-metrics"
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultLookupTimeout        = 10 * time.Second
+	defaultMaxConcurrentLookups = 64
 )
 
 var (
@@ -21,16 +29,63 @@ type syncPeerService struct {
 	blockchain Blockchain       // reference to the blockchain module
 	network    Network          // reference to the network module
 	stream     *grpc.GrpcStream // reference to the grpc stream
+
+	lookupTimeout time.Duration // deadline of a single blockchain lookup, 0 means no deadline
+	// slots of the goroutines running blockchain lookups, a slot is held until the lookup
+	// returns even if the request gave up on it. nil means no bound
+	lookupSem chan struct{}
+
+	// reports the highest block number known from peers and whether the node is syncing,
+	// nil means the node reports its latest block as the highest one and isn't syncing
+	syncProgress func() (highest uint64, syncing bool)
+}
+
+type SyncPeerServiceOption func(*syncPeerService)
+
+// WithLookupTimeout sets how long a request handler waits for a single blockchain lookup,
+// zero value means handlers wait until the request is canceled
+func WithLookupTimeout(timeout time.Duration) SyncPeerServiceOption {
+	return func(s *syncPeerService) {
+		s.lookupTimeout = timeout
+	}
+}
+
+// WithMaxConcurrentLookups bounds the number of requests running blockchain lookups at once,
+// requests over the bound fail right away. Zero value means no bound
+func WithMaxConcurrentLookups(n int) SyncPeerServiceOption {
+	return func(s *syncPeerService) {
+		s.lookupSem = nil
+
+		if n > 0 {
+			s.lookupSem = make(chan struct{}, n)
+		}
+	}
+}
+
+// withSyncProgress sets the source of the sync progress reported by GetSyncProgress
+func withSyncProgress(syncProgress func() (highest uint64, syncing bool)) SyncPeerServiceOption {
+	return func(s *syncPeerService) {
+		s.syncProgress = syncProgress
+	}
 }
 
 func NewSyncPeerService(
 	network Network,
 	blockchain Blockchain,
+	opts ...SyncPeerServiceOption,
 ) SyncPeerService {
-	return &syncPeerService{
-		blockchain: blockchain,
-		network:    network,
+	s := &syncPeerService{
+		blockchain:    blockchain,
+		network:       network,
+		lookupTimeout: defaultLookupTimeout,
+		lookupSem:     make(chan struct{}, defaultMaxConcurrentLookups),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // Start starts syncPeerService
@@ -57,23 +112,93 @@ func (s *syncPeerService) GetBlocks(
 	req *proto.GetBlocksRequest,
 	stream proto.SyncPeer_GetBlocksServer,
 ) error {
-	// from to latest
-	for i := req.From; i <= s.blockchain.Header().Number; i++ {
-		block, ok := s.blockchain.GetBlockByNumber(i, true)
-		if !ok {
-			return ErrBlockNotFound
-		}
+	// stops the lookups once the request returns
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
 
-		resp := toProtoBlock(block)
-		metrics.SetGauge([]string{syncerMetrics, "egress_bytes"}, float32(len(resp.Block)))
+	var (
+		blockCh = make(chan *types.Block)
+		// set before blockCh is closed
+		lookupErr error
+	)
 
-		// if client closes stream, context.Canceled is given
-		if err := stream.Send(resp); err != nil {
-			break
+	// all the lookups of the request run in a single goroutine, from to latest
+	if _, err := s.startLookups(func() {
+		defer close(blockCh)
+
+		for i := req.From; ctx.Err() == nil; i++ {
+			if i > s.blockchain.Header().Number {
+				return
+			}
+
+			block, ok := s.blockchain.GetBlockByNumber(i, true)
+			if !ok {
+				lookupErr = ErrBlockNotFound
+
+				return
+			}
+
+			select {
+			case blockCh <- block:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}); err != nil {
+		return err
+	}
+
+	// the lookup timeout bounds the wait for every block, measured by a single timer of the request
+	var (
+		timer   *time.Timer
+		timerCh <-chan time.Time
+	)
+
+	if s.lookupTimeout > 0 {
+		timer = time.NewTimer(s.lookupTimeout)
+		defer timer.Stop()
+
+		timerCh = timer.C
 	}
 
-	return nil
+	for {
+		select {
+		case block, ok := <-blockCh:
+			if !ok {
+				if lookupErr == nil && ctx.Err() != nil {
+					return toLookupError(ctx.Err())
+				}
+
+				return lookupErr
+			}
+
+			// stop sending blocks once the client canceled the request
+			if err := ctx.Err(); err != nil {
+				return toLookupError(err)
+			}
+
+			// sending isn't a lookup, the timer is rearmed once the block is sent
+			if timer != nil && !timer.Stop() {
+				<-timer.C
+			}
+
+			resp := toProtoBlock(block)
+			metrics.SetGauge([]string{syncerMetrics, "egress_bytes"}, float32(len(resp.Block)))
+
+			// if client closes stream, context.Canceled is given
+			if err := stream.Send(resp); err != nil {
+				return nil
+			}
+
+			if timer != nil {
+				timer.Reset(s.lookupTimeout)
+			}
+		case <-ctx.Done():
+			return toLookupError(ctx.Err())
+		case <-timerCh:
+			return toLookupError(context.DeadlineExceeded)
+		}
+	}
 }
 
 // GetStatus is a gRPC endpoint to return the latest block number as a node status
@@ -81,16 +206,123 @@ func (s *syncPeerService) GetStatus(
 	ctx context.Context,
 	req *empty.Empty,
 ) (*proto.SyncPeerStatus, error) {
+	var header *types.Header
+
+	if err := s.lookup(ctx, func() {
+		header = s.blockchain.Header()
+	}); err != nil {
+		return nil, err
+	}
+
 	var number uint64
-	if header := s.blockchain.Header(); header != nil {
+	if header != nil {
 		number = header.Number
 	}
 
 	return &proto.SyncPeerStatus{
-		Number: number,
+		Number:       number,
+		Capabilities: capabilities,
 	}, nil
 }
 
+// GetSyncProgress is a gRPC endpoint to return the latest block number, the highest block number
+// known from peers and whether the node is syncing
+func (s *syncPeerService) GetSyncProgress(
+	ctx context.Context,
+	req *empty.Empty,
+) (*proto.SyncProgress, error) {
+	var header *types.Header
+
+	if err := s.lookup(ctx, func() {
+		header = s.blockchain.Header()
+	}); err != nil {
+		return nil, err
+	}
+
+	progress := &proto.SyncProgress{}
+	if header != nil {
+		progress.Head = header.Number
+	}
+
+	if s.syncProgress != nil {
+		progress.Highest, progress.Syncing = s.syncProgress()
+	}
+
+	// peers behind the node don't lower the highest block
+	progress.Highest = max(progress.Highest, progress.Head)
+
+	return progress, nil
+}
+
+// Ping is a gRPC endpoint to check liveness and round-trip time, it does no lookup
+func (s *syncPeerService) Ping(
+	ctx context.Context,
+	req *empty.Empty,
+) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// lookup runs the blockchain lookup and waits until it's done, the request is canceled
+// or the lookup timeout elapses, whichever comes first. The lookup can't be interrupted,
+// so its results must only be used if no error is returned
+func (s *syncPeerService) lookup(ctx context.Context, lookup func()) error {
+	if s.lookupTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, s.lookupTimeout)
+		defer cancel()
+	}
+
+	doneCh, err := s.startLookups(lookup)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		return toLookupError(ctx.Err())
+	}
+}
+
+// startLookups runs the lookups in a goroutine holding a slot of the lookup semaphore,
+// the returned channel is closed once they return. It fails right away if there is no free slot
+func (s *syncPeerService) startLookups(lookups func()) (<-chan struct{}, error) {
+	if s.lookupSem != nil {
+		select {
+		case s.lookupSem <- struct{}{}:
+		default:
+			return nil, status.Error(codes.ResourceExhausted, "too many blockchain lookups in progress")
+		}
+	}
+
+	doneCh := make(chan struct{})
+
+	go func() {
+		defer close(doneCh)
+
+		if s.lookupSem != nil {
+			defer func() {
+				<-s.lookupSem
+			}()
+		}
+
+		lookups()
+	}()
+
+	return doneCh, nil
+}
+
+// toLookupError converts the error of the request context to gRPC status error
+func toLookupError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "blockchain lookup timed out")
+	}
+
+	return status.Error(codes.Canceled, err.Error())
+}
+
 // toProtoBlock converts type.Block -> proto.Block
 func toProtoBlock(block *types.Block) *proto.Block {
 	return &proto.Block{
@@ -6,12 +6,15 @@ import (
 	"log"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/0xPolygon/polygon-edge/syncer/proto"
 	"github.com/0xPolygon/polygon-edge/types"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -136,6 +139,61 @@ func Test_syncPeerService_GetBlocks(t *testing.T) {
 	}
 }
 
+type mockGetBlocksServer struct {
+	grpc.ServerStream
+
+	ctx         context.Context
+	sendHandler func(*proto.Block) error
+}
+
+func (m *mockGetBlocksServer) Context() context.Context {
+	return m.ctx
+}
+
+func (m *mockGetBlocksServer) Send(b *proto.Block) error {
+	return m.sendHandler(b)
+}
+
+func Test_syncPeerService_GetBlocks_Canceled(t *testing.T) {
+	t.Parallel()
+
+	blocks := createMockBlocks(10)
+
+	service := &syncPeerService{
+		blockchain: &mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(10),
+			getBlockByNumberHandler: func(u uint64, _ bool) (*types.Block, bool) {
+				return blocks[u-1], true
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sent := 0
+
+	err := service.GetBlocks(
+		&proto.GetBlocksRequest{From: 1},
+		&mockGetBlocksServer{
+			ctx: ctx,
+			sendHandler: func(*proto.Block) error {
+				sent++
+
+				// client goes away after receiving 3 blocks
+				if sent == 3 {
+					cancel()
+				}
+
+				return nil
+			},
+		},
+	)
+
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Equal(t, 3, sent)
+}
+
 func TestGetStatus(t *testing.T) {
 	t.Parallel()
 
@@ -153,4 +211,173 @@ func TestGetStatus(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, headerNumber, status.Number)
+	assert.Equal(t, []string{FeatureSyncProgress, FeaturePing}, status.Capabilities)
+}
+
+func Test_syncPeerService_Ping(t *testing.T) {
+	t.Parallel()
+
+	// no lookup is done, the blockchain isn't touched
+	service := &syncPeerService{}
+
+	client := newMockGrpcClient(t, service)
+
+	_, err := client.Ping(context.Background(), &emptypb.Empty{})
+
+	assert.NoError(t, err)
+}
+
+func Test_syncPeerService_GetSyncProgress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		syncProgress func() (uint64, bool)
+		expected     *proto.SyncProgress
+	}{
+		{
+			name:     "should report own latest block as highest without progress source",
+			expected: &proto.SyncProgress{Head: 10, Highest: 10},
+		},
+		{
+			name: "should report highest block of peers and syncing",
+			syncProgress: func() (uint64, bool) {
+				return 20, true
+			},
+			expected: &proto.SyncProgress{Head: 10, Highest: 20, Syncing: true},
+		},
+		{
+			name: "should not report highest block below own latest block",
+			syncProgress: func() (uint64, bool) {
+				return 5, false
+			},
+			expected: &proto.SyncProgress{Head: 10, Highest: 10},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := &syncPeerService{
+				blockchain: &mockBlockchain{
+					headerHandler: newSimpleHeaderHandler(10),
+				},
+				syncProgress: test.syncProgress,
+			}
+
+			client := newMockGrpcClient(t, service)
+
+			progress, err := client.GetSyncProgress(context.Background(), &emptypb.Empty{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected.Head, progress.Head)
+			assert.Equal(t, test.expected.Highest, progress.Highest)
+			assert.Equal(t, test.expected.Syncing, progress.Syncing)
+		})
+	}
+}
+
+func Test_syncPeerService_LookupTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks = createMockBlocks(10)
+		// slow storage, blocks the lookups until the test ends
+		unblockCh = make(chan struct{})
+	)
+
+	t.Cleanup(func() {
+		close(unblockCh)
+	})
+
+	service := &syncPeerService{
+		blockchain: &mockBlockchain{
+			headerHandler: func() *types.Header {
+				<-unblockCh
+
+				return blocks[len(blocks)-1].Header
+			},
+			getBlockByNumberHandler: func(u uint64, _ bool) (*types.Block, bool) {
+				<-unblockCh
+
+				return blocks[u-1], true
+			},
+		},
+		lookupTimeout: 100 * time.Millisecond,
+	}
+
+	t.Run("GetStatus", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := service.GetStatus(context.Background(), &emptypb.Empty{})
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("GetBlocks", func(t *testing.T) {
+		t.Parallel()
+
+		err := service.GetBlocks(
+			&proto.GetBlocksRequest{From: 1},
+			&mockGetBlocksServer{
+				ctx: context.Background(),
+				sendHandler: func(*proto.Block) error {
+					return nil
+				},
+			},
+		)
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+}
+
+func Test_syncPeerService_MaxConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// slow storage, blocks the lookups until unblocked
+		unblockCh = make(chan struct{})
+		service   = &syncPeerService{
+			blockchain: &mockBlockchain{
+				headerHandler: func() *types.Header {
+					<-unblockCh
+
+					return &types.Header{Number: 10}
+				},
+			},
+			lookupTimeout: 100 * time.Millisecond,
+		}
+	)
+
+	WithMaxConcurrentLookups(1)(service)
+
+	// the request gives up on the lookup, but the lookup keeps its slot until it returns
+	_, err := service.GetStatus(context.Background(), &emptypb.Empty{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	_, err = service.GetStatus(context.Background(), &emptypb.Empty{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	err = service.GetBlocks(
+		&proto.GetBlocksRequest{From: 1},
+		&mockGetBlocksServer{
+			ctx: context.Background(),
+			sendHandler: func(*proto.Block) error {
+				return nil
+			},
+		},
+	)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the slot is freed once the lookup returns
+	close(unblockCh)
+
+	assert.Eventually(t, func() bool {
+		res, err := service.GetStatus(context.Background(), &emptypb.Empty{})
+
+		return err == nil && res.Number == 10
+	}, time.Second, 10*time.Millisecond)
 }
@@ -0,0 +1,98 @@
+package syncer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// SyncSession is a bulk sync session with a single peer in progress
+type SyncSession struct {
+	// PeerID is the ID of the peer the blocks are requested from
+	PeerID peer.ID
+	// StartHeight is the first block number requested from the peer
+	StartHeight uint64
+	// TargetHeight is the latest block number the peer advertised
+	TargetHeight uint64
+	// StartedAt is the time the session started
+	StartedAt time.Time
+
+	blocksWritten atomic.Uint64
+	cancel        context.CancelCauseFunc
+}
+
+// BlocksWritten returns the number of blocks written to the local chain in the session so far
+func (s *SyncSession) BlocksWritten() uint64 {
+	return s.blocksWritten.Load()
+}
+
+// Cancel stops the session, the session returns context.Canceled
+func (s *SyncSession) Cancel() {
+	s.cancel(nil)
+}
+
+// switchPeer stops the session in favor of a better peer, the session returns errSyncPeerSwitched
+func (s *SyncSession) switchPeer() {
+	s.cancel(errSyncPeerSwitched)
+}
+
+// syncSessionSet keeps track of the sync sessions in progress
+type syncSessionSet struct {
+	lock     sync.Mutex
+	sessions []*SyncSession
+}
+
+// start registers a new session with the peer, the returned context is canceled
+// once the session is canceled or ctx is done
+func (ss *syncSessionSet) start(
+	ctx context.Context,
+	peerID peer.ID,
+	startHeight, targetHeight uint64,
+) (*SyncSession, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	session := &SyncSession{
+		PeerID:       peerID,
+		StartHeight:  startHeight,
+		TargetHeight: targetHeight,
+		StartedAt:    time.Now().UTC(),
+		cancel:       cancel,
+	}
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.sessions = append(ss.sessions, session)
+
+	return session, ctx
+}
+
+// finish cancels the session and unregisters it
+func (ss *syncSessionSet) finish(session *SyncSession) {
+	session.cancel(nil)
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	for i, s := range ss.sessions {
+		if s == session {
+			ss.sessions = append(ss.sessions[:i], ss.sessions[i+1:]...)
+
+			break
+		}
+	}
+}
+
+// list returns the sessions in progress, oldest first
+func (ss *syncSessionSet) list() []*SyncSession {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	sessions := make([]*SyncSession, len(ss.sessions))
+	copy(sessions, ss.sessions)
+
+	return sessions
+}
@@ -0,0 +1,180 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/blockchain"
+	"github.com/0xPolygon/polygon-edge/helper/progress"
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_syncSessionSet(t *testing.T) {
+	t.Parallel()
+
+	set := new(syncSessionSet)
+
+	sessionA, ctxA := set.start(context.Background(), peer.ID("A"), 1, 10)
+	sessionB, ctxB := set.start(context.Background(), peer.ID("B"), 11, 20)
+
+	assert.Equal(t, []*SyncSession{sessionA, sessionB}, set.list())
+	assert.Equal(t, uint64(11), sessionB.StartHeight)
+	assert.Equal(t, uint64(20), sessionB.TargetHeight)
+
+	sessionA.Cancel()
+
+	assert.ErrorIs(t, ctxA.Err(), context.Canceled)
+	assert.NoError(t, ctxB.Err())
+
+	// canceled session is listed until it's finished
+	assert.Len(t, set.list(), 2)
+
+	set.finish(sessionA)
+	set.finish(sessionB)
+
+	assert.ErrorIs(t, ctxB.Err(), context.Canceled)
+	assert.Empty(t, set.list())
+}
+
+func Test_bulkSyncWithPeer_CancelSession(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks = createMockBlocks(10)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(*types.FullBlock) error {
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+					return blocksToCh(blocks, 20*time.Millisecond), nil
+				},
+			},
+			&mockProgression{},
+		)
+
+		resultCh = make(chan *BulkSyncResult, 1)
+		errCh    = make(chan error, 1)
+	)
+
+	go func() {
+		result, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("A"), 10,
+			func(*types.FullBlock) bool { return false })
+
+		resultCh <- result
+		errCh <- err
+	}()
+
+	var session *SyncSession
+
+	require.Eventually(t, func() bool {
+		sessions := syncer.SyncSessions()
+		if len(sessions) != 1 {
+			return false
+		}
+
+		session = sessions[0]
+
+		return session.BlocksWritten() >= 2
+	}, 5*time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, peer.ID("A"), session.PeerID)
+	assert.Equal(t, uint64(1), session.StartHeight)
+	assert.Equal(t, uint64(10), session.TargetHeight)
+
+	session.Cancel()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("bulk sync didn't return after the session was canceled")
+	}
+
+	result := <-resultCh
+
+	assert.Less(t, result.BlocksWritten, uint64(len(blocks)))
+	assert.Equal(t, result.BlocksWritten, session.BlocksWritten())
+	assert.Empty(t, syncer.SyncSessions())
+}
+
+func Test_bulkSyncWithPeer_ConcurrentSessionsProgression(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks       = createMockBlocks(10)
+		subscription = blockchain.NewMockSubscription()
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				subscription:  subscription,
+				headerHandler: newSimpleHeaderHandler(0),
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(*types.FullBlock) error {
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, _ uint64, _ time.Duration) (<-chan *types.Block, error) {
+					if id == peer.ID("A") {
+						return blocksToCh(blocks[:2], 0), nil
+					}
+
+					return blocksToCh(blocks, 50*time.Millisecond), nil
+				},
+			},
+			progress.NewProgressionWrapper(progress.ChainSyncBulk),
+		)
+
+		errCh = make(chan error, 1)
+	)
+
+	syncer.syncSessionSem = make(chan struct{}, 2)
+
+	go func() {
+		_, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("B"), 10,
+			func(*types.FullBlock) bool { return false })
+
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return syncer.GetSyncProgression() != nil
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// a shorter session starts and ends while B is still syncing
+	_, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("A"), 2,
+		func(*types.FullBlock) bool { return false })
+	require.NoError(t, err)
+
+	progression := syncer.GetSyncProgression()
+	require.NotNil(t, progression)
+	assert.Equal(t, uint64(10), progression.HighestBlock)
+
+	// progression of B keeps being updated
+	select {
+	case subscription.GetEventCh() <- &blockchain.Event{NewChain: []*types.Header{{Number: 5}}}:
+	case <-time.After(time.Second):
+		t.Fatal("progression stopped with the first session")
+	}
+
+	assert.NoError(t, <-errCh)
+	assert.Nil(t, syncer.GetSyncProgression())
+}
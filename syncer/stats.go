@@ -0,0 +1,225 @@
+package syncer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// latencySmoothingFactor is the weight of a new sample in the latency moving average
+	latencySmoothingFactor = 0.2
+	// peerFailureWindow is how long after its last failure the failures of a peer are forgotten
+	peerFailureWindow = time.Hour
+)
+
+// peerStats holds what the syncer measured about a peer,
+// unlike NoForkPeer it survives peer status updates
+type peerStats struct {
+	lock sync.RWMutex
+
+	// exponentially-weighted moving average of round-trip time
+	latency time.Duration
+	// number of failed requests and sync sessions, halved by every successful sync session
+	// and forgotten once the peer hasn't failed for peerFailureWindow
+	failures uint64
+	// last time a failure was recorded
+	lastFailureAt time.Time
+	// number of probes failed in a row
+	consecutiveProbeFailures uint64
+	// number of sync sessions failed in a row
+	consecutiveSyncFailures uint64
+	// the peer isn't synced with until this time
+	backoffUntil time.Time
+	// last time anything was recorded about the peer
+	updatedAt time.Time
+	// last time a status gossiped by the peer was received
+	statusReceivedAt time.Time
+}
+
+// Latency returns the average round-trip time of the peer, 0 if not measured yet
+func (s *peerStats) Latency() time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.latency
+}
+
+// updateLatency adds a new round-trip time sample to the moving average
+func (s *peerStats) updateLatency(rtt time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.updatedAt = time.Now()
+
+	if s.latency == 0 {
+		s.latency = rtt
+
+		return
+	}
+
+	s.latency = time.Duration(latencySmoothingFactor*float64(rtt) + (1-latencySmoothingFactor)*float64(s.latency))
+}
+
+// Failures returns the number of failures attributed to the peer
+func (s *peerStats) Failures() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if time.Since(s.lastFailureAt) > peerFailureWindow {
+		return 0
+	}
+
+	return s.failures
+}
+
+// addFailure records a failure attributed to the peer
+func (s *peerStats) addFailure() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.recordFailure(time.Now())
+}
+
+// recordFailure counts a failure at the given time, the failures out of the window are dropped first.
+// The caller must hold the lock
+func (s *peerStats) recordFailure(now time.Time) {
+	if now.Sub(s.lastFailureAt) > peerFailureWindow {
+		s.failures = 0
+	}
+
+	s.failures++
+	s.lastFailureAt = now
+	s.updatedAt = now
+}
+
+// addProbeFailure records a failed probe and returns the number of probes failed in a row
+func (s *peerStats) addProbeFailure() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.recordFailure(time.Now())
+	s.consecutiveProbeFailures++
+
+	return s.consecutiveProbeFailures
+}
+
+// recordStatus records that a status gossiped by the peer was received
+func (s *peerStats) recordStatus(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.statusReceivedAt = now
+}
+
+// statusAge returns the time since the last status gossiped by the peer was received
+func (s *peerStats) statusAge(now time.Time) time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return now.Sub(s.statusReceivedAt)
+}
+
+// resetProbeFailures resets the number of probes failed in a row
+func (s *peerStats) resetProbeFailures() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.consecutiveProbeFailures = 0
+}
+
+// addSyncFailure records a failed sync session and backs the peer off,
+// the backoff starts with base and doubles with every failure in a row up to maxBackoff
+func (s *peerStats) addSyncFailure(now time.Time, base, maxBackoff time.Duration) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.recordFailure(now)
+	s.consecutiveSyncFailures++
+
+	backoff := base
+	for i := uint64(1); i < s.consecutiveSyncFailures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	s.backoffUntil = now.Add(backoff)
+
+	return backoff
+}
+
+// resetSyncFailures clears the backoff and halves the failures after a successful sync session
+func (s *peerStats) resetSyncFailures() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.failures /= 2
+	s.consecutiveSyncFailures = 0
+	s.backoffUntil = time.Time{}
+	s.updatedAt = time.Now()
+}
+
+// inBackoff returns whether the peer shouldn't be synced with at the given time
+func (s *peerStats) inBackoff(now time.Time) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return now.Before(s.backoffUntil)
+}
+
+// bytesCounter counts bytes received from each peer and in total,
+// zero value is ready to use
+type bytesCounter struct {
+	total atomic.Uint64
+	// peer ID -> *atomic.Uint64
+	peers sync.Map
+}
+
+// add adds the number of bytes received from the peer
+func (c *bytesCounter) add(peerID peer.ID, n uint64) {
+	value, _ := c.peers.LoadOrStore(peerID, new(atomic.Uint64))
+	counter, _ := value.(*atomic.Uint64)
+
+	counter.Add(n)
+	c.total.Add(n)
+}
+
+// get returns the number of bytes received from the peer
+func (c *bytesCounter) get(peerID peer.ID) uint64 {
+	value, ok := c.peers.Load(peerID)
+	if !ok {
+		return 0
+	}
+
+	counter, _ := value.(*atomic.Uint64)
+
+	return counter.Load()
+}
+
+// remove drops the counter of the peer, the bytes stay in total
+func (c *bytesCounter) remove(peerID peer.ID) {
+	c.peers.Delete(peerID)
+}
+
+// peerStatsMap is a concurrent map of peer ID to peerStats
+type peerStatsMap struct {
+	sync.Map
+}
+
+// get returns the stats of the given peer, creates them if they don't exist
+func (m *peerStatsMap) get(peerID peer.ID) *peerStats {
+	value, _ := m.LoadOrStore(peerID.String(), &peerStats{})
+	stats, _ := value.(*peerStats)
+
+	return stats
+}
+
+// remove removes the stats of the given peer
+func (m *peerStatsMap) remove(peerID peer.ID) {
+	m.Delete(peerID.String())
+}
@@ -0,0 +1,122 @@
+package syncer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/helper/common"
+)
+
+// peerStatsRecord is the persisted form of peerStats,
+// probe failures in a row aren't kept as the peer is probed afresh after a restart
+type peerStatsRecord struct {
+	Latency                 time.Duration `json:"latency"`
+	Failures                uint64        `json:"failures"`
+	LastFailureAt           time.Time     `json:"lastFailureAt"`
+	ConsecutiveSyncFailures uint64        `json:"consecutiveSyncFailures"`
+	BackoffUntil            time.Time     `json:"backoffUntil"`
+	UpdatedAt               time.Time     `json:"updatedAt"`
+}
+
+// record returns the persisted form of the stats
+func (s *peerStats) record() peerStatsRecord {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return peerStatsRecord{
+		Latency:                 s.latency,
+		Failures:                s.failures,
+		LastFailureAt:           s.lastFailureAt,
+		ConsecutiveSyncFailures: s.consecutiveSyncFailures,
+		BackoffUntil:            s.backoffUntil,
+		UpdatedAt:               s.updatedAt,
+	}
+}
+
+// snapshot returns the persisted form of the stats of all the peers keyed by peer ID,
+// stats with nothing recorded are skipped
+func (m *peerStatsMap) snapshot() map[string]peerStatsRecord {
+	records := make(map[string]peerStatsRecord)
+
+	m.Range(func(key, value interface{}) bool {
+		id, _ := key.(string)
+		stats, _ := value.(*peerStats)
+
+		if record := stats.record(); !record.UpdatedAt.IsZero() {
+			records[id] = record
+		}
+
+		return true
+	})
+
+	return records
+}
+
+// restore loads the records into the map, records updated more than maxAge before now are dropped,
+// 0 maxAge keeps all of them
+func (m *peerStatsMap) restore(records map[string]peerStatsRecord, now time.Time, maxAge time.Duration) {
+	for id, record := range records {
+		if maxAge > 0 && record.UpdatedAt.Add(maxAge).Before(now) {
+			continue
+		}
+
+		m.Store(id, &peerStats{
+			latency:                 record.Latency,
+			failures:                record.Failures,
+			lastFailureAt:           record.LastFailureAt,
+			consecutiveSyncFailures: record.ConsecutiveSyncFailures,
+			backoffUntil:            record.BackoffUntil,
+			updatedAt:               record.UpdatedAt,
+		})
+	}
+}
+
+// prune removes the stats updated more than maxAge before now, 0 maxAge keeps all of them
+func (m *peerStatsMap) prune(now time.Time, maxAge time.Duration) {
+	if maxAge == 0 {
+		return
+	}
+
+	m.Range(func(key, value interface{}) bool {
+		stats, _ := value.(*peerStats)
+
+		if record := stats.record(); record.UpdatedAt.Add(maxAge).Before(now) {
+			m.Delete(key)
+		}
+
+		return true
+	})
+}
+
+// savePeerStats writes the stats of all the peers to the file at path
+func savePeerStats(path string, m *peerStatsMap) error {
+	data, err := json.Marshal(m.snapshot())
+	if err != nil {
+		return fmt.Errorf("failed to encode peer stats: %w", err)
+	}
+
+	return common.SaveFileSafe(path, data, 0600)
+}
+
+// loadPeerStats reads the stats written by savePeerStats from the file at path,
+// a missing file yields no records
+func loadPeerStats(path string) (map[string]peerStatsRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	records := make(map[string]peerStatsRecord)
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode peer stats: %w", err)
+	}
+
+	return records, nil
+}
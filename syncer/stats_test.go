@@ -0,0 +1,177 @@
+package syncer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_peerStats_updateLatency(t *testing.T) {
+	t.Parallel()
+
+	stats := &peerStats{}
+
+	// first sample is taken as is
+	stats.updateLatency(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, stats.Latency())
+
+	// following samples are smoothed
+	stats.updateLatency(200 * time.Millisecond)
+	assert.Equal(t, 120*time.Millisecond, stats.Latency())
+}
+
+func Test_peerStats_addSyncFailure(t *testing.T) {
+	t.Parallel()
+
+	var (
+		stats = &peerStats{}
+		now   = time.Now()
+		base  = time.Second
+		limit = 5 * time.Second
+	)
+
+	assert.False(t, stats.inBackoff(now))
+
+	// backoff doubles with every failure in a row up to the limit
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, limit, limit} {
+		assert.Equal(t, expected, stats.addSyncFailure(now, base, limit))
+		assert.True(t, stats.inBackoff(now.Add(expected-time.Millisecond)))
+		assert.False(t, stats.inBackoff(now.Add(expected)))
+	}
+
+	assert.Equal(t, uint64(5), stats.Failures())
+
+	// success resets the backoff
+	stats.resetSyncFailures()
+
+	assert.False(t, stats.inBackoff(now))
+	assert.Equal(t, base, stats.addSyncFailure(now, base, limit))
+}
+
+func Test_peerStats_FailureDecay(t *testing.T) {
+	t.Parallel()
+
+	var (
+		stats = &peerStats{}
+		now   = time.Now()
+	)
+
+	for i := 0; i < 4; i++ {
+		stats.addSyncFailure(now, time.Second, time.Minute)
+	}
+
+	assert.Equal(t, uint64(4), stats.Failures())
+
+	// every successful session halves the failures
+	stats.resetSyncFailures()
+	assert.Equal(t, uint64(2), stats.Failures())
+
+	stats.resetSyncFailures()
+	assert.Equal(t, uint64(1), stats.Failures())
+
+	// failures are forgotten once the peer hasn't failed within the window
+	stats = &peerStats{}
+
+	stats.addSyncFailure(now.Add(-2*peerFailureWindow), time.Second, time.Minute)
+	stats.addSyncFailure(now.Add(-2*peerFailureWindow), time.Second, time.Minute)
+
+	assert.Zero(t, stats.Failures())
+
+	// and counting starts over with the next failure
+	stats.addSyncFailure(now, time.Second, time.Minute)
+
+	assert.Equal(t, uint64(1), stats.Failures())
+}
+
+func Test_bytesCounter(t *testing.T) {
+	t.Parallel()
+
+	var c bytesCounter
+
+	c.add(peer.ID("A"), 100)
+	c.add(peer.ID("B"), 50)
+	c.add(peer.ID("A"), 20)
+
+	assert.Equal(t, uint64(120), c.get(peer.ID("A")))
+	assert.Equal(t, uint64(50), c.get(peer.ID("B")))
+	assert.Zero(t, c.get(peer.ID("C")))
+	assert.Equal(t, uint64(170), c.total.Load())
+
+	// removed peer's bytes stay in total
+	c.remove(peer.ID("A"))
+
+	assert.Zero(t, c.get(peer.ID("A")))
+	assert.Equal(t, uint64(170), c.total.Load())
+}
+
+func Test_peerStatsMap(t *testing.T) {
+	t.Parallel()
+
+	m := new(peerStatsMap)
+
+	stats := m.get(peer.ID("A"))
+	stats.updateLatency(time.Second)
+
+	// same instance is returned for the same peer
+	assert.Same(t, stats, m.get(peer.ID("A")))
+
+	m.remove(peer.ID("A"))
+
+	assert.Zero(t, m.get(peer.ID("A")).Latency())
+}
+
+func Test_peerStats_Persistence(t *testing.T) {
+	t.Parallel()
+
+	var (
+		path   = filepath.Join(t.TempDir(), "peer_stats.json")
+		now    = time.Now()
+		maxAge = time.Hour
+		m      = new(peerStatsMap)
+	)
+
+	m.get(peer.ID("A")).updateLatency(100 * time.Millisecond)
+	m.get(peer.ID("A")).addSyncFailure(now, time.Minute, time.Hour)
+
+	// B was last heard of before maxAge
+	m.get(peer.ID("B")).addSyncFailure(now.Add(-2*maxAge), time.Minute, time.Hour)
+
+	// C has nothing recorded
+	m.get(peer.ID("C"))
+
+	require.NoError(t, savePeerStats(path, m))
+
+	// simulate restart
+	records, err := loadPeerStats(path)
+	require.NoError(t, err)
+
+	restored := new(peerStatsMap)
+	restored.restore(records, now, maxAge)
+
+	statsA := restored.get(peer.ID("A"))
+	assert.Equal(t, 100*time.Millisecond, statsA.Latency())
+	assert.Equal(t, uint64(1), statsA.Failures())
+	assert.True(t, statsA.inBackoff(now))
+	assert.False(t, statsA.inBackoff(now.Add(time.Minute)))
+
+	_, ok := restored.Load(peer.ID("B").String())
+	assert.False(t, ok)
+
+	_, ok = restored.Load(peer.ID("C").String())
+	assert.False(t, ok)
+
+	// stats are never kept forever
+	syncer := &syncer{}
+	WithPeerStatsPersistence(path, 0, 0)(syncer)
+
+	assert.Equal(t, defaultPeerStatsMaxAge, syncer.peerStatsMaxAge)
+
+	// missing file yields no records
+	records, err = loadPeerStats(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
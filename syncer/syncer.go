@@ -1,10 +1,17 @@
 package syncer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/0xPolygon/polygon-edge/blockchain"
 	"github.com/0xPolygon/polygon-edge/helper/progress"
 	"github.com/0xPolygon/polygon-edge/network/event"
 	"github.com/0xPolygon/polygon-edge/types"
@@ -16,16 +23,39 @@ import (
 const (
 	syncerName  = "syncer"
 	syncerProto = "/syncer/0.2"
+
+	defaultMaxPeerProbeFailures = 3
+	defaultNewStatusBufferSize  = 1
+	defaultPeerStatsMaxAge      = 24 * time.Hour
+
+	// writeBlockAttempts is the number of attempts to write a verified block,
+	// write errors are local and may be transient
+	writeBlockAttempts   = 3
+	writeBlockRetryDelay = 100 * time.Millisecond
+
+	// peerStatusAttempts is the number of attempts to fetch the status of a newly connected peer,
+	// the first request may fail while the connection is still being set up.
+	// The delay between attempts is doubled after each one
+	peerStatusAttempts   = 3
+	peerStatusRetryDelay = 200 * time.Millisecond
 )
 
 var (
-	errTimeout = errors.New("timeout awaiting block from peer")
+	errTimeout               = errors.New("timeout awaiting block from peer")
+	errPeerLatestBlockMissed = errors.New("peer failed to serve its advertised latest block")
+	errBlockNotContiguous    = errors.New("block doesn't follow the previous block")
+	errInvalidBlock          = errors.New("unable to verify block")
+	errWriteBlockFailed      = errors.New("failed to write block while bulk syncing")
+	errPeerNotFound          = errors.New("peer not found in peer map")
+	errSyncPeerSwitched      = errors.New("sync session canceled in favor of a better peer")
+	errSyncerClosed          = errors.New("syncer is closed")
 )
 
 // XXX: Don't use this syncer for the consensus that may cause fork.
 // This syncer doesn't assume forks
 type syncer struct {
 	logger          hclog.Logger
+	network         Network
 	blockchain      Blockchain
 	syncProgression Progression
 
@@ -35,9 +65,321 @@ type syncer struct {
 
 	// Timeout for syncing a block
 	blockTimeout time.Duration
+	// Timeout of a whole bulk sync session with a peer, 0 means no limit
+	sessionTimeout time.Duration
 
 	// Channel to notify Sync that a new status arrived
 	newStatusCh chan struct{}
+	// Number of notifications kept in newStatusCh while Sync is busy
+	newStatusBufferSize int
+	// Channels of the syncs to target notified of a new status alongside newStatusCh,
+	// so that they don't take the notifications of the running Sync
+	statusWaitersLock sync.Mutex
+	statusWaiters     map[chan struct{}]struct{}
+
+	// Statistics measured about peers
+	peerStats *peerStatsMap
+	// Interval of refreshing peers status and measuring their round-trip time, 0 disables it (default)
+	peerProbeInterval time.Duration
+	// Number of probes failed in a row after which the peer is evicted, 0 disables eviction
+	maxPeerProbeFailures uint64
+	// Weights of the peer score, nil means the best peer is picked by latest block only
+	peerScoreWeights *PeerScoreWeights
+	// File the peer stats are persisted to, empty disables persistence
+	peerStatsPath string
+	// Interval of flushing the peer stats to the file, 0 means they're only flushed on close
+	peerStatsFlushInterval time.Duration
+	// Age after which the stats of a peer nothing was recorded about are dropped
+	peerStatsMaxAge time.Duration
+	// Options passed to the sync peer client on creation
+	syncPeerClientOpts []SyncPeerClientOption
+	// Options passed to the sync peer service on creation
+	syncPeerServiceOpts []SyncPeerServiceOption
+
+	// Maximum number of peers in peer map, the worst peer is evicted to make room for a better one,
+	// 0 means no limit
+	maxPeers int
+	// Lock making room for a peer and putting it to peer map a single step,
+	// so that concurrent updates can't grow peer map over maxPeers
+	peerMapLock sync.Mutex
+	// Maximum number of peers to sync with simultaneously
+	maxSyncPeers int
+	// Semaphore bounding the number of sync sessions, nil means no bound
+	syncSessionSem chan struct{}
+	// Number of sync sessions running at the moment
+	activeSyncSessions atomic.Int64
+	// Bulk sync sessions in progress
+	sessions *syncSessionSet
+
+	// Sync progression is shared by the running sessions, it's tracked from the start
+	// of the first one until the end of the last one
+	progressionLock    sync.Mutex
+	progressionOwners  int
+	progressionSub     blockchain.Subscription
+	progressionHighest uint64
+
+	// Allowlist and denylist of peers to sync with
+	peerAccessList *peerAccessList
+	// How long a peer disconnected by DisconnectPeer is not synced with, 0 means until restart
+	disconnectCooldown time.Duration
+
+	// Subscribers of block import results
+	importResults *importResultFeed
+
+	// Latest sync decisions, nil disables tracing
+	tracer *syncTracer
+
+	// Backoff of a peer after its first failed sync session, doubled with every failure in a row,
+	// 0 disables backoff
+	syncBackoffBase time.Duration
+	// Maximum backoff of a peer
+	syncBackoffMax time.Duration
+
+	// Number of blocks downloaded from a single peer at a time in multi peer sync
+	segmentSize uint64
+	// Bounds of the segment size adapted to the download throughput, 0 keeps the size fixed
+	minSegmentSize uint64
+	maxSegmentSize uint64
+	// Time to download a whole segment before it's reassigned to another peer, 0 means no limit
+	segmentTimeout time.Duration
+	// Maximum number of downloaded but not written blocks in multi peer sync, 0 means the number
+	// is only bounded by the number of segments downloaded ahead
+	maxBlocksInFlight uint64
+
+	// Number of blocks a peer must be ahead of local latest block by more than to start bulk sync,
+	// smaller gaps are left to consensus, 0 means bulk sync starts for a single block lead
+	syncMargin uint64
+
+	// Whether the best peer is asked for its advertised latest block before bulk sync,
+	// it costs an extra stream per session but skips a peer lying about its height upfront
+	peerLatestBlockCheck bool
+
+	// Number of blocks a new best peer must be ahead of the peer of a running sync session
+	// to cancel the session and sync with the new one instead, 0 disables switching
+	peerSwitchMargin uint64
+
+	// Number of peers needed before syncing starts, 0 means syncing starts with any peer
+	minSyncPeers int
+	// Time to wait for minSyncPeers peers before syncing with the peers at hand, 0 means waiting forever
+	minSyncPeersTimeout time.Duration
+	// Set once minSyncPeers peers connected or minSyncPeersTimeout elapsed
+	minSyncPeersReached atomic.Bool
+
+	closeCh chan struct{}
+}
+
+// PeerScoreWeights are the weights of the factors blended in a peer score
+type PeerScoreWeights struct {
+	// Height weights the peer's lead over local latest block, normalized by the highest lead
+	Height float64
+	// Latency weights the inverse of the peer's round-trip time in seconds
+	Latency float64
+	// Failures weights the number of failures attributed to the peer, it's subtracted
+	Failures float64
+}
+
+// DefaultPeerScoreWeights are the peer score weights which favor height but still
+// prefer a responsive and reliable peer among the peers with a similar height
+var DefaultPeerScoreWeights = PeerScoreWeights{
+	Height:   1,
+	Latency:  0.5,
+	Failures: 0.1,
+}
+
+type SyncerOption func(*syncer)
+
+// WithPeerProbeInterval sets how often the status of peers is refreshed
+// and their round-trip time is measured. Probing is disabled by default and with zero value
+func WithPeerProbeInterval(interval time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.peerProbeInterval = interval
+	}
+}
+
+// WithMaxPeerProbeFailures sets the number of probes failed in a row
+// after which the peer is considered dead and evicted, zero value disables eviction
+func WithMaxPeerProbeFailures(failures uint64) SyncerOption {
+	return func(s *syncer) {
+		s.maxPeerProbeFailures = failures
+	}
+}
+
+// WithPeerStatsPersistence persists the stats of peers to the file at path, so that the failures
+// and backoffs of peers survive restarts. The stats are loaded on start, flushed every flushInterval
+// and on close, stats not updated within maxAge are dropped, zero maxAge means defaultPeerStatsMaxAge
+func WithPeerStatsPersistence(path string, flushInterval, maxAge time.Duration) SyncerOption {
+	return func(s *syncer) {
+		if maxAge <= 0 {
+			maxAge = defaultPeerStatsMaxAge
+		}
+
+		s.peerStatsPath = path
+		s.peerStatsFlushInterval = flushInterval
+		s.peerStatsMaxAge = maxAge
+	}
+}
+
+// WithSyncTrace records the latest size sync decisions, such as peer scores, picked peers
+// and session and segment outcomes, retrievable by SyncTrace. Zero value disables tracing
+func WithSyncTrace(size int) SyncerOption {
+	return func(s *syncer) {
+		if size > 0 {
+			s.tracer = newSyncTracer(size)
+		} else {
+			s.tracer = nil
+		}
+	}
+}
+
+// WithMaxPeers caps the number of peers tracked, once reached a new peer replaces the worst peer
+// if it's better, by score if peer score weights are set, otherwise it's ignored. Zero value means no limit
+func WithMaxPeers(maxPeers int) SyncerOption {
+	return func(s *syncer) {
+		s.maxPeers = maxPeers
+	}
+}
+
+// WithMaxSyncPeers sets the maximum number of peers to sync with simultaneously,
+// extra sync sessions wait until a running one finishes. Sessions aren't limited by default
+// and with zero value
+func WithMaxSyncPeers(maxSyncPeers int) SyncerOption {
+	return func(s *syncer) {
+		s.maxSyncPeers = maxSyncPeers
+	}
+}
+
+// WithMinSyncPeers makes syncer wait for the given number of peers before syncing starts,
+// so that the initial sync isn't locked onto the first peer. After the timeout syncer
+// syncs with the peers at hand, zero timeout means waiting forever
+func WithMinSyncPeers(minSyncPeers int, timeout time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.minSyncPeers = minSyncPeers
+		s.minSyncPeersTimeout = timeout
+	}
+}
+
+// WithSyncBackoff sets how long a peer is skipped after a failed sync session,
+// the backoff starts with base and doubles with every failure in a row up to maxBackoff.
+// Peers aren't backed off by default and with zero base
+func WithSyncBackoff(base, maxBackoff time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.syncBackoffBase = base
+		s.syncBackoffMax = maxBackoff
+	}
+}
+
+// WithSessionTimeout bounds the time of a bulk sync session with a single peer, a session
+// not finished in time is abandoned and sync continues with another peer, zero value means no limit
+func WithSessionTimeout(timeout time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.sessionTimeout = timeout
+	}
+}
+
+// WithSegmentSize sets the number of blocks downloaded from a single peer at a time in multi peer sync
+func WithSegmentSize(size uint64) SyncerOption {
+	return func(s *syncer) {
+		s.segmentSize = size
+	}
+}
+
+// WithAdaptiveSegmentSize makes multi peer sync adapt the segment size to the download throughput
+// within the given bounds, the size grows while the throughput improves and is halved once
+// a download fails. Zero values keep the size fixed
+func WithAdaptiveSegmentSize(minSize, maxSize uint64) SyncerOption {
+	return func(s *syncer) {
+		s.minSegmentSize = minSize
+		s.maxSegmentSize = maxSize
+	}
+}
+
+// WithSegmentTimeout sets the time to download a whole segment in multi peer sync,
+// a segment not downloaded in time is reassigned to another peer, zero value means no limit
+func WithSegmentTimeout(timeout time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.segmentTimeout = timeout
+	}
+}
+
+// WithMaxBlocksInFlight bounds the number of blocks downloaded but not written yet in multi peer sync.
+// The segment next to write is always downloaded, so the limit should be at least the segment size.
+// Zero value keeps the number only bounded by the number of segments downloaded ahead
+func WithMaxBlocksInFlight(blocks uint64) SyncerOption {
+	return func(s *syncer) {
+		s.maxBlocksInFlight = blocks
+	}
+}
+
+// WithSyncMargin sets the number of blocks the best peer must be ahead of local latest block
+// by more than to start bulk sync, zero value starts bulk sync for a single block lead
+func WithSyncMargin(blocks uint64) SyncerOption {
+	return func(s *syncer) {
+		s.syncMargin = blocks
+	}
+}
+
+// WithNewStatusBufferSize sets the number of new status notifications kept while Sync is busy
+// with a peer. Sync reads the whole peer map on each notification, so a single kept notification
+// is enough for a status received during a sync session not to be missed. Notifications beyond
+// the buffer are dropped, zero value drops every notification Sync isn't waiting for
+func WithNewStatusBufferSize(size int) SyncerOption {
+	return func(s *syncer) {
+		s.newStatusBufferSize = size
+	}
+}
+
+// WithPeerLatestBlockVerification makes Sync request the advertised latest block of the best peer
+// before syncing with it and skip the peer if it doesn't serve the block. Disabled by default,
+// a lying peer is then only skipped once its bulk sync session ends short of the advertised height
+func WithPeerLatestBlockVerification(enabled bool) SyncerOption {
+	return func(s *syncer) {
+		s.peerLatestBlockCheck = enabled
+	}
+}
+
+// WithPeerSwitchMargin sets the number of blocks a new best peer must be ahead of the peer
+// being synced with to switch to the new one, zero value disables switching
+func WithPeerSwitchMargin(blocks uint64) SyncerOption {
+	return func(s *syncer) {
+		s.peerSwitchMargin = blocks
+	}
+}
+
+// WithDisconnectCooldown lets a peer disconnected by DisconnectPeer back after the given duration,
+// zero value keeps it out until restart
+func WithDisconnectCooldown(cooldown time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.disconnectCooldown = cooldown
+	}
+}
+
+// WithPeerAccessLists restricts the peers to sync with, see SetPeerAccessLists
+func WithPeerAccessLists(allowlist, denylist []peer.ID) SyncerOption {
+	return func(s *syncer) {
+		s.peerAccessList.set(allowlist, denylist)
+	}
+}
+
+// WithPeerScoreWeights makes syncer pick the peer to sync with by score
+// computed with the given weights instead of by latest block only
+func WithPeerScoreWeights(weights PeerScoreWeights) SyncerOption {
+	return func(s *syncer) {
+		s.peerScoreWeights = &weights
+	}
+}
+
+// WithSyncPeerClientOptions sets the options the sync peer client is created with
+func WithSyncPeerClientOptions(opts ...SyncPeerClientOption) SyncerOption {
+	return func(s *syncer) {
+		s.syncPeerClientOpts = append(s.syncPeerClientOpts, opts...)
+	}
+}
+
+// WithSyncPeerServiceOptions sets the options the sync peer service is created with
+func WithSyncPeerServiceOptions(opts ...SyncPeerServiceOption) SyncerOption {
+	return func(s *syncer) {
+		s.syncPeerServiceOpts = append(s.syncPeerServiceOpts, opts...)
+	}
 }
 
 func NewSyncer(
@@ -45,17 +387,39 @@ func NewSyncer(
 	network Network,
 	blockchain Blockchain,
 	blockTimeout time.Duration,
+	opts ...SyncerOption,
 ) Syncer {
-	return &syncer{
-		logger:          logger.Named(syncerName),
-		blockchain:      blockchain,
-		syncProgression: progress.NewProgressionWrapper(progress.ChainSyncBulk),
-		syncPeerService: NewSyncPeerService(network, blockchain),
-		syncPeerClient:  NewSyncPeerClient(logger, network, blockchain),
-		blockTimeout:    blockTimeout,
-		newStatusCh:     make(chan struct{}),
-		peerMap:         new(PeerMap),
+	s := &syncer{
+		logger:               logger.Named(syncerName),
+		network:              network,
+		blockchain:           blockchain,
+		syncProgression:      progress.NewProgressionWrapper(progress.ChainSyncBulk),
+		blockTimeout:         blockTimeout,
+		newStatusBufferSize:  defaultNewStatusBufferSize,
+		peerMap:              new(PeerMap),
+		peerStats:            new(peerStatsMap),
+		maxPeerProbeFailures: defaultMaxPeerProbeFailures,
+		segmentSize:          defaultSegmentSize,
+		peerAccessList:       new(peerAccessList),
+		importResults:        new(importResultFeed),
+		sessions:             new(syncSessionSet),
+		closeCh:              make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	s.newStatusCh = make(chan struct{}, s.newStatusBufferSize)
+	s.syncPeerService = NewSyncPeerService(network, blockchain,
+		append(s.syncPeerServiceOpts, withSyncProgress(s.syncProgress))...)
+	s.syncPeerClient = NewSyncPeerClient(logger, network, blockchain, s.syncPeerClientOpts...)
+
+	if s.maxSyncPeers > 0 {
+		s.syncSessionSem = make(chan struct{}, s.maxSyncPeers)
+	}
+
+	return s
 }
 
 // Start starts goroutine processes
@@ -66,17 +430,35 @@ func (s *syncer) Start() error {
 
 	s.syncPeerService.Start()
 
+	if s.peerStatsPath != "" {
+		s.loadPeerStats()
+	}
+
 	s.initializePeerMap()
 
 	go s.startPeerStatusUpdateProcess()
 	go s.startPeerConnectionEventProcess()
 
+	if s.peerProbeInterval > 0 {
+		go s.startPeerProbeProcess()
+	}
+
+	if s.peerStatsPath != "" && s.peerStatsFlushInterval > 0 {
+		go s.startPeerStatsFlushProcess()
+	}
+
 	return nil
 }
 
 // Close terminates goroutine processes
 func (s *syncer) Close() error {
-	close(s.newStatusCh)
+	// newStatusCh is left open, the peer processes may still notify of a status
+	// while they're stopping and a send on a closed channel panics
+	close(s.closeCh)
+
+	if s.peerStatsPath != "" {
+		s.flushPeerStats()
+	}
 
 	if err := s.syncPeerService.Close(); err != nil {
 		return err
@@ -90,12 +472,20 @@ func (s *syncer) Close() error {
 // initializePeerMap fetches peer statuses and initializes map
 func (s *syncer) initializePeerMap() {
 	peerStatuses := s.syncPeerClient.GetConnectedPeerStatuses()
-	s.peerMap.Put(peerStatuses...)
+
+	for _, status := range peerStatuses {
+		if s.isPeerAccepted(status.ID) {
+			s.admitToPeerMap(status)
+		}
+	}
+
+	s.updatePeerCountMetric()
 }
 
 // startPeerStatusUpdateProcess subscribes peer status change event and updates peer map
 func (s *syncer) startPeerStatusUpdateProcess() {
 	for peerStatus := range s.syncPeerClient.GetPeerStatusUpdateCh() {
+		s.peerStats.get(peerStatus.ID).recordStatus(time.Now())
 		s.putToPeerMap(peerStatus)
 	}
 }
@@ -114,164 +504,1208 @@ func (s *syncer) startPeerConnectionEventProcess() {
 	}
 }
 
-// initNewPeerStatus fetches status of the peer and put to peer map
+// initNewPeerStatus fetches status of the peer and put to peer map,
+// the status request is retried a few times in case of a transient failure
 func (s *syncer) initNewPeerStatus(peerID peer.ID) {
-	status, err := s.syncPeerClient.GetPeerStatus(peerID)
-	if err != nil {
-		s.logger.Warn("failed to get peer status, skip", "id", peerID, "err", err)
+	delay := peerStatusRetryDelay
 
-		return
-	}
+	for attempt := 1; ; attempt++ {
+		status, err := s.syncPeerClient.GetPeerStatus(peerID)
+		if err == nil {
+			s.putToPeerMap(status)
+
+			return
+		}
+
+		if attempt == peerStatusAttempts {
+			s.logger.Warn("failed to get peer status, skip", "id", peerID, "attempts", attempt, "err", err)
+
+			return
+		}
+
+		s.logger.Debug("failed to get peer status, retrying", "id", peerID, "attempt", attempt, "err", err)
+
+		select {
+		case <-s.closeCh:
+			return
+		case <-time.After(delay):
+		}
 
-	s.putToPeerMap(status)
+		delay *= 2
+	}
 }
 
 // putToPeerMap puts given status to peer map
 func (s *syncer) putToPeerMap(status *NoForkPeer) {
-	s.peerMap.Put(status)
+	if !s.isPeerAccepted(status.ID) || !s.admitToPeerMap(status) {
+		return
+	}
+
+	s.updatePeerCountMetric()
+	s.switchToBetterPeer(status)
 	s.notifyNewStatusEvent()
 }
 
-// removeFromPeerMap removes the peer from peer map
-func (s *syncer) removeFromPeerMap(peerID peer.ID) {
-	s.peerMap.Remove(peerID)
-}
+// admitToPeerMap puts given status to peer map if there is room for the peer,
+// returns whether the status was put
+func (s *syncer) admitToPeerMap(status *NoForkPeer) bool {
+	s.peerMapLock.Lock()
+	defer s.peerMapLock.Unlock()
 
-// notifyNewStatusEvent emits signal to newStatusCh
-func (s *syncer) notifyNewStatusEvent() {
-	select {
-	case s.newStatusCh <- struct{}{}:
-	default:
+	if !s.makeRoomForPeer(status) {
+		return false
 	}
-}
 
-// GetSyncProgression returns progression
-func (s *syncer) GetSyncProgression() *progress.Progression {
-	return s.syncProgression.GetProgression()
+	s.peerMap.Put(status)
+
+	return true
 }
 
-// HasSyncPeer returns whether syncer has the peer to syncs blocks
-// return false if syncer has no peer whose latest block height doesn't exceed local height
-func (s *syncer) HasSyncPeer() bool {
-	bestPeer := s.peerMap.BestPeer(nil)
-	header := s.blockchain.Header()
+// makeRoomForPeer returns whether the peer fits in peer map. If peer map is full,
+// the worst peer is evicted in favor of the new one unless the new one is the worst itself
+func (s *syncer) makeRoomForPeer(status *NoForkPeer) bool {
+	if s.maxPeers <= 0 || s.peerMap.Len() < s.maxPeers {
+		return true
+	}
 
-	return bestPeer != nil && bestPeer.Number > header.Number
-}
+	// status update of a known peer
+	if _, ok := s.peerMap.Load(status.ID.String()); ok {
+		return true
+	}
 
-// Sync syncs block with the best peer until callback returns true
-func (s *syncer) Sync(callback func(*types.FullBlock) bool) error {
-	localLatest := s.blockchain.Header().Number
-	skipList := make(map[peer.ID]bool)
+	isWorse := s.peerComparator(status.Number)
 
-	for {
-		// Wait for a new event to arrive
-		<-s.newStatusCh
+	worstPeer := s.peerMap.WorstPeer(isWorse)
+	if worstPeer == nil || !isWorse(worstPeer, status) {
+		s.logger.Debug("reject peer", "id", status.ID, "reason", "peer map is full")
 
-		// fetch local latest block
-		if header := s.blockchain.Header(); header != nil {
-			localLatest = header.Number
+		if err := s.syncPeerClient.CloseStream(status.ID); err != nil {
+			s.logger.Debug("failed to close stream to rejected peer", "id", status.ID, "err", err)
 		}
 
-		// pick one best peer
-		bestPeer := s.peerMap.BestPeer(skipList)
-		if bestPeer == nil {
-			// Empty skipList map if there are no best peers
-			skipList = make(map[peer.ID]bool)
+		return false
+	}
 
-			continue
-		}
+	s.logger.Info("peer map is full, evict worst peer", "id", worstPeer.ID, "number", worstPeer.Number,
+		"new peer", status.ID, "new number", status.Number)
 
-		// if the bestPeer does not have a new block continue
-		if bestPeer.Number <= localLatest {
-			continue
+	s.removeFromPeerMap(worstPeer.ID)
+
+	if err := s.syncPeerClient.CloseStream(worstPeer.ID); err != nil {
+		s.logger.Debug("failed to close stream to evicted peer", "id", worstPeer.ID, "err", err)
+	}
+
+	return true
+}
+
+// peerComparator returns a function telling whether peer p is worse than peer t,
+// by score if peer score weights are set, otherwise by latest block and distance.
+// number is the latest block of a peer not in peer map yet, taken into account for the score
+func (s *syncer) peerComparator(number uint64) func(p, t *NoForkPeer) bool {
+	if s.peerScoreWeights == nil {
+		return func(p, t *NoForkPeer) bool {
+			return t.IsBetter(p)
 		}
+	}
 
-		// fetch block from the peer
-		lastNumber, shouldTerminate, err := s.bulkSyncWithPeer(bestPeer.ID, bestPeer.Number, callback)
-		if err != nil {
-			s.logger.Warn("failed to complete bulk sync with peer, try to next one", "peer ID", "error", bestPeer.ID, err)
+	var localLatest uint64
+	if header := s.blockchain.Header(); header != nil {
+		localLatest = header.Number
+	}
+
+	highest := number
+	if bestPeer := s.peerMap.BestPeer(nil); bestPeer != nil && bestPeer.Number > highest {
+		highest = bestPeer.Number
+	}
+
+	return func(p, t *NoForkPeer) bool {
+		pScore := s.peerScore(p, localLatest, highest)
+		tScore := s.peerScore(t, localLatest, highest)
+
+		if pScore != tScore {
+			return pScore < tScore
 		}
 
-		if lastNumber < bestPeer.Number {
-			skipList[bestPeer.ID] = true
+		return t.IsBetter(p)
+	}
+}
 
-			// continue to next peer
+// switchToBetterPeer cancels the running sync sessions in case the peer became the best peer
+// and is ahead of the session peer by more than peer switch margin
+func (s *syncer) switchToBetterPeer(status *NoForkPeer) {
+	if s.peerSwitchMargin == 0 {
+		return
+	}
+
+	if bestPeer := s.peerMap.BestPeer(nil); bestPeer == nil || bestPeer.ID != status.ID {
+		return
+	}
+
+	for _, session := range s.sessions.list() {
+		if session.PeerID == status.ID {
 			continue
 		}
 
-		if shouldTerminate {
-			break
+		// the session peer may have advanced since the session started
+		sessionPeerNumber := session.TargetHeight
+		if value, ok := s.peerMap.Load(session.PeerID.String()); ok {
+			if p, _ := value.(*NoForkPeer); p.Number > sessionPeerNumber {
+				sessionPeerNumber = p.Number
+			}
 		}
-	}
 
-	return nil
+		if status.Number < sessionPeerNumber+s.peerSwitchMargin {
+			continue
+		}
+
+		s.logger.Info("better peer found, switch sync to it", "from", session.PeerID,
+			"from number", sessionPeerNumber, "to", status.ID, "to number", status.Number)
+
+		session.switchPeer()
+	}
 }
 
-// bulkSyncWithPeer syncs block with a given peer
-func (s *syncer) bulkSyncWithPeer(peerID peer.ID, peerLatestBlock uint64,
-	newBlockCallback func(*types.FullBlock) bool) (uint64, bool, error) {
-	localLatest := s.blockchain.Header().Number
-	shouldTerminate := false
+// isPeerAccepted checks the peer against allowlist and denylist,
+// closes the stream to the peer if it's rejected
+func (s *syncer) isPeerAccepted(peerID peer.ID) bool {
+	// discovery may loop the local node back, there is no stream to close in such case
+	if s.isSelf(peerID) {
+		s.logger.Warn("reject peer", "id", peerID, "reason", errPeerIsSelf)
 
-	blockCh, err := s.syncPeerClient.GetBlocks(peerID, localLatest+1, s.blockTimeout)
-	if err != nil {
-		return 0, false, err
+		return false
 	}
 
-	// Create a blockchain subscription for the sync progression and start tracking
-	subscription := s.blockchain.SubscribeEvents()
-	s.syncProgression.StartProgression(localLatest+1, subscription)
-	s.syncProgression.UpdateHighestProgression(peerLatestBlock)
+	err := s.peerAccessList.check(peerID)
+	if err == nil {
+		return true
+	}
 
-	defer func() {
-		err := s.syncPeerClient.CloseStream(peerID)
-		if err != nil {
-			s.logger.Error("Failed to close stream: ", err)
+	s.logger.Debug("reject peer", "id", peerID, "reason", err)
+
+	if err := s.syncPeerClient.CloseStream(peerID); err != nil {
+		s.logger.Debug("failed to close stream to rejected peer", "id", peerID, "err", err)
+	}
+
+	return false
+}
+
+// isSelf returns whether the peer is the local node
+func (s *syncer) isSelf(peerID peer.ID) bool {
+	return s.network != nil && s.network.AddrInfo().ID == peerID
+}
+
+// SetPeerAccessLists replaces the lists of peers syncer may sync with.
+// If allowlist is not empty, only the peers in it are synced with,
+// the peers in denylist are never synced with. Peers in peer map
+// that are no longer accepted are removed
+func (s *syncer) SetPeerAccessLists(allowlist, denylist []peer.ID) {
+	s.peerAccessList.set(allowlist, denylist)
+
+	s.peerMap.Range(func(key, value interface{}) bool {
+		p, _ := value.(*NoForkPeer)
+
+		if !s.isPeerAccepted(p.ID) {
+			s.removeFromPeerMap(p.ID)
 		}
 
-		// Stop monitoring the sync progression upon exit
-		s.syncProgression.StopProgression()
-		s.blockchain.UnsubscribeEvents(subscription)
-	}()
+		return true
+	})
+}
 
-	var lastReceivedNumber uint64
+// DisconnectPeer drops the peer from peer map and closes the connection to it.
+// The peer is not synced with until the disconnect cooldown passes, whatever
+// the lists set by SetPeerAccessLists are
+func (s *syncer) DisconnectPeer(peerID peer.ID) error {
+	if _, ok := s.peerMap.Load(peerID.String()); !ok {
+		return fmt.Errorf("%w: %s", errPeerNotFound, peerID)
+	}
 
-	for {
-		select {
-		case block, ok := <-blockCh:
-			if !ok {
-				return lastReceivedNumber, shouldTerminate, nil
-			}
+	var until time.Time
+	if s.disconnectCooldown > 0 {
+		until = time.Now().Add(s.disconnectCooldown)
+	}
 
-			// safe check
-			if block.Number() == 0 {
-				continue
-			}
+	s.peerAccessList.disconnect(peerID, until)
+	s.removeFromPeerMap(peerID)
 
-			fullBlock, err := s.blockchain.VerifyFinalizedBlock(block)
-			if err != nil {
-				metrics.IncrCounter([]string{syncerMetrics, "bad_block"}, 1)
+	if err := s.syncPeerClient.CloseStream(peerID); err != nil {
+		s.logger.Debug("failed to close stream to disconnected peer", "id", peerID, "err", err)
+	}
 
-				return lastReceivedNumber, false, fmt.Errorf("unable to verify block, %w", err)
-			}
+	if s.network != nil {
+		s.network.DisconnectFromPeer(peerID, "disconnected by syncer")
+	}
 
-			if err := s.blockchain.WriteFullBlock(fullBlock, syncerName); err != nil {
-				metrics.IncrCounter([]string{syncerMetrics, "bad_block"}, 1)
+	return nil
+}
 
-				return lastReceivedNumber, false, fmt.Errorf("failed to write block while bulk syncing: %w", err)
-			}
+// removeFromPeerMap removes the peer from peer map
+func (s *syncer) removeFromPeerMap(peerID peer.ID) {
+	s.peerMap.Remove(peerID)
 
-			updateMetrics(fullBlock)
-			shouldTerminate = newBlockCallback(fullBlock)
+	// persisted stats are kept until they get stale, so that the peer is judged by them on reconnect
+	if s.peerStatsPath == "" {
+		s.peerStats.remove(peerID)
+	}
 
-			lastReceivedNumber = block.Number()
-		case <-time.After(s.blockTimeout):
-			return lastReceivedNumber, shouldTerminate, errTimeout
+	s.updatePeerCountMetric()
+}
+
+// updatePeerCountMetric sets the number of sync peers metric to the size of peer map
+func (s *syncer) updatePeerCountMetric() {
+	metrics.SetGauge([]string{syncerMetrics, "peers"}, float32(s.peerMap.Len()))
+}
+
+// startPeerProbeProcess refreshes status and measures round-trip time of the peers periodically
+func (s *syncer) startPeerProbeProcess() {
+	ticker := time.NewTicker(s.peerProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			s.probePeers()
 		}
 	}
 }
 
+// loadPeerStats restores the peer stats from the file, stale ones are dropped.
+// Syncer starts with no stats if the file can't be read
+func (s *syncer) loadPeerStats() {
+	records, err := loadPeerStats(s.peerStatsPath)
+	if err != nil {
+		s.logger.Warn("failed to load peer stats", "path", s.peerStatsPath, "err", err)
+
+		return
+	}
+
+	s.peerStats.restore(records, time.Now(), s.peerStatsMaxAge)
+}
+
+// startPeerStatsFlushProcess flushes the peer stats to the file periodically
+func (s *syncer) startPeerStatsFlushProcess() {
+	ticker := time.NewTicker(s.peerStatsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			s.flushPeerStats()
+		}
+	}
+}
+
+// flushPeerStats drops the stale peer stats and writes the rest to the file
+func (s *syncer) flushPeerStats() {
+	s.peerStats.prune(time.Now(), s.peerStatsMaxAge)
+
+	if err := savePeerStats(s.peerStatsPath, s.peerStats); err != nil {
+		s.logger.Error("failed to save peer stats", "path", s.peerStatsPath, "err", err)
+	}
+}
+
+// probePeers probes all the peers in peer map concurrently, see probePeer.
+// Status gossip may be missed, so the peer map would get stale without it
+func (s *syncer) probePeers() {
+	var wg sync.WaitGroup
+
+	s.peerMap.Range(func(key, value interface{}) bool {
+		p, _ := value.(*NoForkPeer)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			stats := s.peerStats.get(p.ID)
+
+			if err := s.probePeer(p, stats); err != nil {
+				s.logger.Debug("failed to probe peer", "id", p.ID, "err", err)
+
+				if failures := stats.addProbeFailure(); s.maxPeerProbeFailures > 0 &&
+					failures >= s.maxPeerProbeFailures {
+					s.evictDeadPeer(p.ID, failures)
+				}
+
+				return
+			}
+
+			stats.resetProbeFailures()
+		}()
+
+		return true
+	})
+
+	wg.Wait()
+}
+
+// probePeer records the round-trip time of the peer and updates peer map with its status.
+// The peer serving Ping is pinged, and its status is only requested if it hasn't gossiped
+// any status since the last probe. Other peers are probed with a status request
+func (s *syncer) probePeer(p *NoForkPeer, stats *peerStats) error {
+	pinged := false
+
+	if p.Supports(FeaturePing) {
+		rtt, err := s.syncPeerClient.Ping(p.ID)
+		if err != nil {
+			return err
+		}
+
+		stats.updateLatency(rtt)
+
+		if stats.statusAge(time.Now()) < s.peerProbeInterval {
+			return nil
+		}
+
+		pinged = true
+	}
+
+	start := time.Now()
+
+	status, err := s.syncPeerClient.GetPeerStatus(p.ID)
+	if err != nil {
+		return err
+	}
+
+	if !pinged {
+		stats.updateLatency(time.Since(start))
+	}
+
+	// the peer may have been removed in the meantime
+	if _, ok := s.peerMap.Load(p.ID.String()); ok {
+		s.putToPeerMap(status)
+	}
+
+	return nil
+}
+
+// notifyNewStatusEvent emits signal to newStatusCh and the status waiters without blocking,
+// the signal is dropped for a channel whose buffer is full
+func (s *syncer) notifyNewStatusEvent() {
+	select {
+	case s.newStatusCh <- struct{}{}:
+	default:
+	}
+
+	s.statusWaitersLock.Lock()
+	defer s.statusWaitersLock.Unlock()
+
+	for ch := range s.statusWaiters {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribeNewStatus returns a channel notified of new statuses alongside newStatusCh
+// and the function to unsubscribe it
+func (s *syncer) subscribeNewStatus() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, s.newStatusBufferSize)
+
+	s.statusWaitersLock.Lock()
+	defer s.statusWaitersLock.Unlock()
+
+	if s.statusWaiters == nil {
+		s.statusWaiters = make(map[chan struct{}]struct{})
+	}
+
+	s.statusWaiters[ch] = struct{}{}
+
+	return ch, func() {
+		s.statusWaitersLock.Lock()
+		defer s.statusWaitersLock.Unlock()
+
+		delete(s.statusWaiters, ch)
+	}
+}
+
+// startProgression starts tracking the sync progression from the given block unless
+// another session tracks it already, the highest block is raised to the given one
+func (s *syncer) startProgression(startingBlock, highestBlock uint64) {
+	s.progressionLock.Lock()
+	defer s.progressionLock.Unlock()
+
+	s.progressionOwners++
+
+	if s.progressionOwners == 1 {
+		s.progressionSub = s.blockchain.SubscribeEvents()
+		s.progressionHighest = 0
+		s.syncProgression.StartProgression(startingBlock, s.progressionSub)
+	}
+
+	if highestBlock > s.progressionHighest {
+		s.progressionHighest = highestBlock
+		s.syncProgression.UpdateHighestProgression(highestBlock)
+	}
+}
+
+// stopProgression stops tracking the sync progression once the last session sharing it ends
+func (s *syncer) stopProgression() {
+	s.progressionLock.Lock()
+	defer s.progressionLock.Unlock()
+
+	s.progressionOwners--
+
+	if s.progressionOwners > 0 {
+		return
+	}
+
+	s.syncProgression.StopProgression()
+	s.blockchain.UnsubscribeEvents(s.progressionSub)
+	s.progressionSub = nil
+}
+
+// GetSyncProgression returns progression
+func (s *syncer) GetSyncProgression() *progress.Progression {
+	return s.syncProgression.GetProgression()
+}
+
+// HasSyncPeer returns whether syncer has the peer to syncs blocks
+// return false if syncer has no peer whose latest block height doesn't exceed local height
+func (s *syncer) HasSyncPeer() bool {
+	bestPeer := s.peerMap.BestPeer(nil)
+	header := s.blockchain.Header()
+
+	return bestPeer != nil && bestPeer.Number > header.Number+s.syncMargin
+}
+
+// HighestKnownBlock returns the highest block number advertised by the peers
+// and the peer that advertised it, zero values if there is no peer
+func (s *syncer) HighestKnownBlock() (uint64, peer.ID) {
+	bestPeer := s.peerMap.BestPeer(nil)
+	if bestPeer == nil {
+		return 0, ""
+	}
+
+	return bestPeer.Number, bestPeer.ID
+}
+
+// syncProgress returns the highest block number advertised by the peers
+// and whether the node is syncing, it's the progress reported to peers
+func (s *syncer) syncProgress() (uint64, bool) {
+	highest, _ := s.HighestKnownBlock()
+
+	return highest, s.GetSyncProgression() != nil
+}
+
+// PeerSyncProgress requests the sync progress the peer reports of itself.
+// A peer which doesn't serve it is only known to be at its latest block
+func (s *syncer) PeerSyncProgress(peerID peer.ID) (*SyncProgress, error) {
+	var status *NoForkPeer
+
+	if value, ok := s.peerMap.Load(peerID.String()); ok {
+		status, _ = value.(*NoForkPeer)
+	} else {
+		var err error
+
+		if status, err = s.syncPeerClient.GetPeerStatus(peerID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !status.Supports(FeatureSyncProgress) {
+		return &SyncProgress{Head: status.Number, Highest: status.Number}, nil
+	}
+
+	return s.syncPeerClient.GetPeerSyncProgress(peerID)
+}
+
+// PeerInfo is a snapshot of the status of a sync peer
+type PeerInfo struct {
+	// ID is the identifier of the peer
+	ID peer.ID
+	// Number is the latest block number the peer advertised
+	Number uint64
+	// Distance is the distance between the node and the peer
+	Distance *big.Int
+	// Latency is the average round-trip time of the peer, 0 if not measured yet
+	Latency time.Duration
+	// Failures is the number of failures attributed to the peer
+	Failures uint64
+	// DownloadedBytes is the number of bytes of blocks received from the peer
+	DownloadedBytes uint64
+}
+
+// Peers returns a snapshot of the sync peers sorted by ID
+func (s *syncer) Peers() []PeerInfo {
+	peers := make([]PeerInfo, 0)
+
+	s.peerMap.Range(func(key, value interface{}) bool {
+		p, _ := value.(*NoForkPeer)
+		stats := s.peerStats.get(p.ID)
+
+		info := PeerInfo{
+			ID:              p.ID,
+			Number:          p.Number,
+			Latency:         stats.Latency(),
+			Failures:        stats.Failures(),
+			DownloadedBytes: s.syncPeerClient.DownloadedBytes(p.ID),
+		}
+
+		if p.Distance != nil {
+			info.Distance = new(big.Int).Set(p.Distance)
+		}
+
+		peers = append(peers, info)
+
+		return true
+	})
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+
+	return peers
+}
+
+// MinSyncPeersReached returns whether syncer has had enough peers to start syncing
+func (s *syncer) MinSyncPeersReached() bool {
+	if s.minSyncPeersReached.Load() {
+		return true
+	}
+
+	if s.peerMap.Len() < s.minSyncPeers {
+		return false
+	}
+
+	s.minSyncPeersReached.Store(true)
+
+	return true
+}
+
+// Sync syncs block with the best peer until callback returns true
+func (s *syncer) Sync(callback func(*types.FullBlock) bool) error {
+	return s.sync(context.Background(), math.MaxUint64, s.newStatusCh, callback)
+}
+
+// SyncToTarget syncs block with the best peer until local latest block reaches target height,
+// blocks beyond target are never written even if the peer has them
+func (s *syncer) SyncToTarget(ctx context.Context, target uint64) error {
+	if s.blockchain.Header().Number >= target {
+		return nil
+	}
+
+	// Sync may be running at the same time, both need to learn of every new status
+	statusCh, unsubscribe := s.subscribeNewStatus()
+	defer unsubscribe()
+
+	return s.sync(ctx, target, statusCh, func(*types.FullBlock) bool {
+		return false
+	})
+}
+
+// ForceResync runs bulk sync with the given peer up to its latest block,
+// even if the peer isn't the best one or isn't ahead of local chain
+func (s *syncer) ForceResync(ctx context.Context, peerID peer.ID) error {
+	value, ok := s.peerMap.Load(peerID.String())
+	if !ok {
+		return fmt.Errorf("%w: %s", errPeerNotFound, peerID)
+	}
+
+	p, _ := value.(*NoForkPeer)
+
+	result, err := s.bulkSyncWithPeer(ctx, p.ID, p.Number, func(*types.FullBlock) bool {
+		return false
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("forced resync with peer finished", "peer", result.PeerID, "from", result.StartHeight,
+		"to", result.EndHeight, "written", result.BlocksWritten, "duration", result.Duration)
+
+	return nil
+}
+
+// sync syncs block with the best peer until callback returns true or target height is reached,
+// it waits on statusCh for a new status to pick the best peer again
+func (s *syncer) sync(
+	ctx context.Context,
+	target uint64,
+	statusCh <-chan struct{},
+	callback func(*types.FullBlock) bool,
+) error {
+	localLatest := s.blockchain.Header().Number
+	skipList := make(map[peer.ID]bool)
+
+	newBlockCallback := func(b *types.FullBlock) bool {
+		return callback(b) || b.Block.Number() >= target
+	}
+
+	var minSyncPeersTimeoutCh <-chan time.Time
+
+	if !s.MinSyncPeersReached() && s.minSyncPeersTimeout > 0 {
+		timer := time.NewTimer(s.minSyncPeersTimeout)
+		defer timer.Stop()
+
+		minSyncPeersTimeoutCh = timer.C
+	}
+
+	// set when the last session was canceled in favor of a better peer,
+	// the better peer is synced with right away without waiting for a new status
+	peerSwitched := false
+
+	for {
+		// the head may reach target by other means than bulk sync, such as consensus
+		if header := s.blockchain.Header(); header != nil && header.Number >= target {
+			return nil
+		}
+
+		if peerSwitched {
+			peerSwitched = false
+		} else {
+			// Wait for a new event to arrive
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-s.closeCh:
+				return errSyncerClosed
+			case <-statusCh:
+			case <-minSyncPeersTimeoutCh:
+				s.logger.Info("timed out waiting for minimum number of sync peers, sync with peers at hand",
+					"min", s.minSyncPeers, "peers", s.peerMap.Len())
+
+				s.minSyncPeersReached.Store(true)
+			}
+		}
+
+		if !s.MinSyncPeersReached() {
+			continue
+		}
+
+		// fetch local latest block
+		if header := s.blockchain.Header(); header != nil {
+			localLatest = header.Number
+		}
+
+		// pick one best peer
+		bestPeer := s.selectBestPeer(skipList, localLatest)
+		if bestPeer == nil {
+			// Empty skipList map if there are no best peers
+			skipList = make(map[peer.ID]bool)
+
+			continue
+		}
+
+		// if the bestPeer does not have a new block continue
+		if bestPeer.Number <= localLatest {
+			continue
+		}
+
+		// a small lead isn't worth bulk sync unless the peer has the requested target
+		if bestPeer.Number <= localLatest+s.syncMargin && bestPeer.Number < target {
+			continue
+		}
+
+		// make sure the peer is actually able to serve the block it advertised,
+		// otherwise a peer lying about its height would always be picked first
+		if err := s.verifyPeerLatestBlock(ctx, bestPeer); err != nil {
+			// the sync was canceled, the peer is not to blame
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			s.logger.Warn("failed to verify latest block of peer, skip it",
+				"peer ID", bestPeer.ID, "number", bestPeer.Number, "error", err)
+
+			s.tracer.record(TraceRecord{Kind: TracePeerDropped, PeerID: bestPeer.ID, To: bestPeer.Number, Err: err})
+
+			// the peer stays in peer map so that its failure and backoff outlive its next status
+			if isPeerFault(err) {
+				s.addSyncFailure(bestPeer.ID)
+			}
+
+			skipList[bestPeer.ID] = true
+
+			continue
+		}
+
+		s.tracer.record(TraceRecord{Kind: TracePeerSelected, PeerID: bestPeer.ID, To: bestPeer.Number})
+
+		peerTarget := bestPeer.Number
+		if peerTarget > target {
+			peerTarget = target
+		}
+
+		// fetch block from the peer
+		result, err := s.bulkSyncWithPeer(ctx, bestPeer.ID, peerTarget, newBlockCallback)
+		if err != nil {
+			if isPeerFault(err) {
+				s.addSyncFailure(bestPeer.ID)
+			}
+
+			s.logger.Warn("failed to complete bulk sync with peer, try to next one", "peer ID", "error", bestPeer.ID, err)
+		} else {
+			s.peerStats.get(bestPeer.ID).resetSyncFailures()
+		}
+
+		s.logger.Debug("bulk sync with peer finished", "peer", result.PeerID, "from", result.StartHeight,
+			"to", result.EndHeight, "written", result.BlocksWritten, "duration", result.Duration)
+
+		s.tracer.record(TraceRecord{
+			Kind:   TraceSessionFinished,
+			PeerID: result.PeerID,
+			From:   result.StartHeight,
+			To:     result.EndHeight,
+			Err:    err,
+		})
+
+		if result.ShouldTerminate {
+			break
+		}
+
+		if errors.Is(err, errSyncPeerSwitched) {
+			peerSwitched = true
+
+			continue
+		}
+
+		if !result.TargetReached() {
+			skipList[bestPeer.ID] = true
+
+			// continue to next peer
+			continue
+		}
+	}
+
+	return nil
+}
+
+// evictDeadPeer removes the peer which stopped responding from peer map and closes the stream to it,
+// the peer is put back once it publishes a new status
+func (s *syncer) evictDeadPeer(peerID peer.ID, failures uint64) {
+	s.logger.Warn("peer missed too many probes, evict it", "id", peerID, "failures", failures)
+
+	s.removeFromPeerMap(peerID)
+
+	if err := s.syncPeerClient.CloseStream(peerID); err != nil {
+		s.logger.Debug("failed to close stream to dead peer", "id", peerID, "err", err)
+	}
+}
+
+// addSyncFailure records a failed sync session of the peer and backs it off
+func (s *syncer) addSyncFailure(peerID peer.ID) {
+	stats := s.peerStats.get(peerID)
+
+	if s.syncBackoffBase <= 0 {
+		stats.addFailure()
+
+		return
+	}
+
+	backoff := stats.addSyncFailure(time.Now(), s.syncBackoffBase, s.syncBackoffMax)
+
+	s.logger.Debug("back off peer after failed sync", "id", peerID, "backoff", backoff)
+}
+
+// selectBestPeer returns the best peer to sync with, skipping the peers in backoff,
+// the peer is picked by score in case peer score weights are set
+func (s *syncer) selectBestPeer(skipList map[peer.ID]bool, localLatest uint64) *NoForkPeer {
+	skipList = s.withBackedOffPeers(skipList, time.Now())
+
+	bestPeer := s.peerMap.BestPeer(skipList)
+	if bestPeer == nil || bestPeer.Number <= localLatest || s.peerScoreWeights == nil {
+		return bestPeer
+	}
+
+	highest := bestPeer.Number
+
+	return s.peerMap.BestPeerByScore(skipList, localLatest, func(p *NoForkPeer) float64 {
+		score := s.peerScore(p, localLatest, highest)
+
+		s.tracer.record(TraceRecord{Kind: TracePeerScored, PeerID: p.ID, To: p.Number, Score: score})
+
+		return score
+	})
+}
+
+// withBackedOffPeers returns a copy of skip list with the peers in backoff added
+func (s *syncer) withBackedOffPeers(skipList map[peer.ID]bool, now time.Time) map[peer.ID]bool {
+	result := make(map[peer.ID]bool, len(skipList))
+
+	for id, skip := range skipList {
+		result[id] = skip
+	}
+
+	s.peerMap.Range(func(key, value interface{}) bool {
+		p, _ := value.(*NoForkPeer)
+
+		if s.peerStats.get(p.ID).inBackoff(now) {
+			result[p.ID] = true
+		}
+
+		return true
+	})
+
+	return result
+}
+
+// peerScore blends the peer's lead over local latest block, its latency and failures
+// into a single score, higher is better
+func (s *syncer) peerScore(p *NoForkPeer, localLatest, highest uint64) float64 {
+	var (
+		weights = s.peerScoreWeights
+		stats   = s.peerStats.get(p.ID)
+
+		heightScore  float64
+		latencyScore float64
+	)
+
+	if p.Number > localLatest && highest > localLatest {
+		heightScore = float64(p.Number-localLatest) / float64(highest-localLatest)
+	}
+
+	// unmeasured latency gives no bonus
+	if latency := stats.Latency(); latency > 0 {
+		latencyScore = 1 / (1 + latency.Seconds())
+	}
+
+	return weights.Height*heightScore +
+		weights.Latency*latencyScore -
+		weights.Failures*float64(stats.Failures())
+}
+
+// verifyPeerLatestBlock requests the latest block the peer advertised
+// and returns error if the peer doesn't serve it, ctx error is returned as is.
+// It's a no-op unless enabled by WithPeerLatestBlockVerification
+func (s *syncer) verifyPeerLatestBlock(ctx context.Context, p *NoForkPeer) error {
+	if !s.peerLatestBlockCheck {
+		return nil
+	}
+
+	// only one block is needed, cancel the rest of the stream on exit
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blockCh, err := s.syncPeerClient.GetBlocks(streamCtx, p.ID, p.Number, s.blockTimeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	defer func() {
+		if err := s.syncPeerClient.CloseStream(p.ID); err != nil {
+			s.logger.Error("Failed to close stream: ", err)
+		}
+	}()
+
+	select {
+	case block, ok := <-blockCh:
+		// the stream is closed on cancellation as well
+		if !ok && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !ok || block.Number() != p.Number {
+			return errPeerLatestBlockMissed
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(s.blockTimeout):
+		return errTimeout
+	}
+}
+
+// DownloadedBytes returns the number of bytes of blocks received from all peers
+func (s *syncer) DownloadedBytes() uint64 {
+	return s.syncPeerClient.TotalDownloadedBytes()
+}
+
+// ActiveSyncSessions returns the number of sync sessions running at the moment
+func (s *syncer) ActiveSyncSessions() int64 {
+	return s.activeSyncSessions.Load()
+}
+
+// SyncSessions returns the bulk sync sessions in progress, oldest first
+func (s *syncer) SyncSessions() []*SyncSession {
+	return s.sessions.list()
+}
+
+// SyncTrace returns the latest sync decisions recorded, oldest first, nil if tracing is disabled
+func (s *syncer) SyncTrace() []TraceRecord {
+	return s.tracer.list()
+}
+
+// acquireSyncSession waits until the number of running sync sessions drops below the limit
+// and registers a new session
+func (s *syncer) acquireSyncSession(ctx context.Context) error {
+	if s.syncSessionSem != nil {
+		select {
+		case s.syncSessionSem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	active := s.activeSyncSessions.Add(1)
+	metrics.SetGauge([]string{syncerMetrics, "active_sessions"}, float32(active))
+
+	return nil
+}
+
+// releaseSyncSession unregisters a finished sync session
+func (s *syncer) releaseSyncSession() {
+	active := s.activeSyncSessions.Add(-1)
+	metrics.SetGauge([]string{syncerMetrics, "active_sessions"}, float32(active))
+
+	if s.syncSessionSem != nil {
+		<-s.syncSessionSem
+	}
+}
+
+// BulkSyncResult is the outcome of a bulk sync session with a single peer
+type BulkSyncResult struct {
+	// PeerID is the ID of the peer the blocks were requested from
+	PeerID peer.ID
+	// StartHeight is the first block number requested from the peer
+	StartHeight uint64
+	// EndHeight is the number of the last written block, 0 if nothing was written
+	EndHeight uint64
+	// TargetHeight is the latest block number the peer advertised
+	TargetHeight uint64
+	// BlocksWritten is the number of blocks written to the local chain
+	BlocksWritten uint64
+	// Duration is the time spent in the session
+	Duration time.Duration
+	// ShouldTerminate is set when the block callback requested to stop syncing
+	ShouldTerminate bool
+}
+
+// TargetReached returns whether all the blocks advertised by the peer were written
+func (r *BulkSyncResult) TargetReached() bool {
+	return r.EndHeight >= r.TargetHeight
+}
+
+// bulkSyncWithPeer syncs block with a given peer, returns *SyncError on failure
+func (s *syncer) bulkSyncWithPeer(ctx context.Context, peerID peer.ID, peerLatestBlock uint64,
+	newBlockCallback func(*types.FullBlock) bool) (*BulkSyncResult, error) {
+	result := &BulkSyncResult{
+		PeerID:       peerID,
+		TargetHeight: peerLatestBlock,
+	}
+
+	newSyncError := func(phase SyncPhase, number uint64, err error) error {
+		return &SyncError{
+			PeerID: peerID,
+			Phase:  phase,
+			Number: number,
+			Err:    err,
+		}
+	}
+
+	if err := s.acquireSyncSession(ctx); err != nil {
+		return result, newSyncError(SyncPhaseStart, s.blockchain.Header().Number+1, err)
+	}
+
+	defer s.releaseSyncSession()
+
+	if s.sessionTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, s.sessionTimeout)
+		defer cancel()
+	}
+
+	localHeader := s.blockchain.Header()
+	localLatest := localHeader.Number
+	startTime := time.Now().UTC()
+
+	// hash of the last written block, the next block from the peer must be its child
+	parentHash := localHeader.Hash
+
+	result.StartHeight = localLatest + 1
+
+	defer func() {
+		result.Duration = time.Since(startTime)
+
+		metrics.MeasureSince([]string{syncerMetrics, "session_duration"}, startTime)
+	}()
+
+	// the session context makes sure the peer stops streaming blocks once we return
+	session, ctx := s.sessions.start(ctx, peerID, result.StartHeight, peerLatestBlock)
+	defer s.sessions.finish(session)
+
+	blockCh, err := s.syncPeerClient.GetBlocks(ctx, peerID, localLatest+1, s.blockTimeout)
+	if err != nil {
+		return result, newSyncError(SyncPhaseStart, localLatest+1, err)
+	}
+
+	s.startProgression(localLatest+1, peerLatestBlock)
+
+	defer func() {
+		err := s.syncPeerClient.CloseStream(peerID)
+		if err != nil {
+			s.logger.Error("Failed to close stream: ", err)
+		}
+
+		// Stop monitoring the sync progression upon exit
+		s.stopProgression()
+	}()
+
+	for {
+		select {
+		case block, ok := <-blockCh:
+			if !ok {
+				// the stream is closed on cancellation as well
+				if ctx.Err() != nil {
+					return result, newSyncError(SyncPhaseDownload,
+						result.StartHeight+result.BlocksWritten, context.Cause(ctx))
+				}
+
+				return result, nil
+			}
+
+			// safe check
+			if block.Number() == 0 {
+				continue
+			}
+
+			if err := checkBlockContinuity(block, result.StartHeight+result.BlocksWritten, parentHash); err != nil {
+				return result, newSyncError(SyncPhaseDownload, block.Number(), err)
+			}
+
+			fullBlock, err := s.importBlock(ctx, peerID, block)
+			if err != nil {
+				result.ShouldTerminate = false
+
+				phase := SyncPhaseVerify
+				if errors.Is(err, errWriteBlockFailed) {
+					phase = SyncPhaseWrite
+				}
+
+				return result, newSyncError(phase, block.Number(), err)
+			}
+
+			parentHash = block.Hash()
+
+			result.ShouldTerminate = newBlockCallback(fullBlock)
+
+			result.EndHeight = block.Number()
+			result.BlocksWritten++
+
+			session.blocksWritten.Add(1)
+
+			if result.ShouldTerminate {
+				return result, nil
+			}
+		case <-ctx.Done():
+			return result, newSyncError(SyncPhaseDownload,
+				result.StartHeight+result.BlocksWritten, context.Cause(ctx))
+		case <-time.After(s.blockTimeout):
+			// the block is held back by our own download limit, not by the peer
+			if s.syncPeerClient.IsThrottled(blockCh) {
+				continue
+			}
+
+			return result, newSyncError(SyncPhaseDownload, result.StartHeight+result.BlocksWritten, errTimeout)
+		}
+	}
+}
+
+// checkBlockContinuity checks the block has the expected number and links to the expected parent,
+// so that a peer serving gapped or mislinked blocks is caught before the block is verified
+func checkBlockContinuity(block *types.Block, expectedNumber uint64, expectedParentHash types.Hash) error {
+	if block.Number() != expectedNumber {
+		return fmt.Errorf("%w: expected block number %d, got %d",
+			errBlockNotContiguous, expectedNumber, block.Number())
+	}
+
+	if block.ParentHash() != expectedParentHash {
+		return fmt.Errorf("%w: expected parent hash %s of block %d, got %s",
+			errBlockNotContiguous, expectedParentHash, block.Number(), block.ParentHash())
+	}
+
+	return nil
+}
+
+// importBlock verifies and writes the block received from the peer
+// and notifies import result subscribers of the outcome
+func (s *syncer) importBlock(ctx context.Context, peerID peer.ID, block *types.Block) (*types.FullBlock, error) {
+	fullBlock, err := s.verifyAndWriteBlock(ctx, block)
+
+	s.importResults.emit(&ImportResult{
+		PeerID: peerID,
+		Number: block.Number(),
+		Hash:   block.Hash(),
+		Err:    err,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	updateMetrics(fullBlock)
+	metrics.IncrCounter([]string{syncerMetrics, "imported_blocks"}, 1)
+
+	return fullBlock, nil
+}
+
+// verifyAndWriteBlock verifies the block and writes it to chain. Writing is retried
+// on errors of the local store, the block rejected by consensus is invalid
+func (s *syncer) verifyAndWriteBlock(ctx context.Context, block *types.Block) (*types.FullBlock, error) {
+	fullBlock, err := s.blockchain.VerifyFinalizedBlock(block)
+	if err != nil {
+		metrics.IncrCounter([]string{syncerMetrics, "bad_block"}, 1)
+
+		return nil, fmt.Errorf("%w, %w", errInvalidBlock, err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.blockchain.WriteFullBlock(fullBlock, syncerName)
+		if err == nil {
+			return fullBlock, nil
+		}
+
+		// the header is rejected for the data from the peer, writing it again won't help
+		if errors.Is(err, blockchain.ErrProcessHeaders) {
+			metrics.IncrCounter([]string{syncerMetrics, "bad_block"}, 1)
+
+			return nil, fmt.Errorf("%w, %w", errInvalidBlock, err)
+		}
+
+		if attempt == writeBlockAttempts {
+			break
+		}
+
+		s.logger.Debug("failed to write block, retrying", "number", block.Number(), "attempt", attempt, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", errWriteBlockFailed, ctx.Err())
+		case <-time.After(writeBlockRetryDelay):
+		}
+	}
+
+	metrics.IncrCounter([]string{syncerMetrics, "bad_block"}, 1)
+
+	return nil, fmt.Errorf("%w: %w", errWriteBlockFailed, err)
+}
+
+// isPeerFault returns whether the bulk sync error is caused by the peer,
+// errors of the local chain and canceled syncs shouldn't count against the peer
+func isPeerFault(err error) bool {
+	return !errors.Is(err, errWriteBlockFailed) &&
+		!errors.Is(err, errSyncPeerSwitched) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// SubscribeImportResults returns a channel of the results of importing blocks received from peers,
+// the channel is closed once ctx is done. Results are dropped if the subscriber falls behind
+func (s *syncer) SubscribeImportResults(ctx context.Context) <-chan *ImportResult {
+	return s.importResults.subscribe(ctx)
+}
+
 func updateMetrics(fullBlock *types.FullBlock) {
 	metrics.SetGauge([]string{syncerMetrics, "tx_num"}, float32(len(fullBlock.Block.Transactions)))
 	metrics.SetGauge([]string{syncerMetrics, "receipts_num"}, float32(len(fullBlock.Receipts)))
@@ -1,10 +1,14 @@
 package syncer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,21 +16,29 @@ import (
 	"github.com/0xPolygon/polygon-edge/helper/progress"
 	"github.com/0xPolygon/polygon-edge/network/event"
 	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockProgression struct {
+	lock          sync.Mutex
 	startingBlock uint64
 	highestBlock  uint64
 }
 
 func (m *mockProgression) StartProgression(startingBlock uint64, subscription blockchain.Subscription) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.startingBlock = startingBlock
 }
 
 func (m *mockProgression) UpdateHighestProgression(highestBlock uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.highestBlock = highestBlock
 }
 
@@ -79,6 +91,21 @@ func newSimpleHeaderHandler(num uint64) func() *types.Header {
 	}
 }
 
+type mockNetwork struct {
+	Network
+
+	selfID            peer.ID
+	disconnectedPeers []peer.ID
+}
+
+func (m *mockNetwork) AddrInfo() *peer.AddrInfo {
+	return &peer.AddrInfo{ID: m.selfID}
+}
+
+func (m *mockNetwork) DisconnectFromPeer(peerID peer.ID, _ string) {
+	m.disconnectedPeers = append(m.disconnectedPeers, peerID)
+}
+
 type mockSyncPeerService struct{}
 
 func (m *mockSyncPeerService) Start() {}
@@ -92,9 +119,14 @@ func (m *mockProgression) StopProgression() {}
 type mockSyncPeerClient struct {
 	getPeerStatusHandler                  func(peer.ID) (*NoForkPeer, error)
 	getConnectedPeerStatusesHandler       func() []*NoForkPeer
+	getPeerSyncProgressHandler            func(peer.ID) (*SyncProgress, error)
+	pingHandler                           func(peer.ID) (time.Duration, error)
 	getBlocksHandler                      func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error)
 	getPeerStatusUpdateChHandler          func() <-chan *NoForkPeer
 	getPeerConnectionUpdateEventChHandler func() <-chan *event.PeerEvent
+	closeStreamHandler                    func(peer.ID) error
+	isThrottledHandler                    func(<-chan *types.Block) bool
+	downloadedBytes                       map[peer.ID]uint64
 }
 
 func (m *mockSyncPeerClient) DisablePublishingPeerStatus() {}
@@ -115,7 +147,16 @@ func (m *mockSyncPeerClient) GetConnectedPeerStatuses() []*NoForkPeer {
 	return m.getConnectedPeerStatusesHandler()
 }
 
+func (m *mockSyncPeerClient) GetPeerSyncProgress(id peer.ID) (*SyncProgress, error) {
+	return m.getPeerSyncProgressHandler(id)
+}
+
+func (m *mockSyncPeerClient) Ping(id peer.ID) (time.Duration, error) {
+	return m.pingHandler(id)
+}
+
 func (m *mockSyncPeerClient) GetBlocks(
+	_ context.Context,
 	id peer.ID,
 	start uint64,
 	timeoutPerBlock time.Duration,
@@ -131,7 +172,33 @@ func (m *mockSyncPeerClient) GetPeerConnectionUpdateEventCh() <-chan *event.Peer
 	return m.getPeerConnectionUpdateEventChHandler()
 }
 
+func (m *mockSyncPeerClient) DownloadedBytes(peerID peer.ID) uint64 {
+	return m.downloadedBytes[peerID]
+}
+
+func (m *mockSyncPeerClient) TotalDownloadedBytes() uint64 {
+	total := uint64(0)
+
+	for _, n := range m.downloadedBytes {
+		total += n
+	}
+
+	return total
+}
+
+func (m *mockSyncPeerClient) IsThrottled(blockCh <-chan *types.Block) bool {
+	if m.isThrottledHandler != nil {
+		return m.isThrottledHandler(blockCh)
+	}
+
+	return false
+}
+
 func (m *mockSyncPeerClient) CloseStream(peerID peer.ID) error {
+	if m.closeStreamHandler != nil {
+		return m.closeStreamHandler(peerID)
+	}
+
 	return nil
 }
 
@@ -169,6 +236,7 @@ func NewTestSyncer(
 ) *syncer {
 	return &syncer{
 		logger:          hclog.NewNullLogger(),
+		network:         network,
 		blockchain:      blockchain,
 		syncProgression: mockProgression,
 		syncPeerService: &mockSyncPeerService{},
@@ -176,6 +244,11 @@ func NewTestSyncer(
 		blockTimeout:    blockTimeout,
 		newStatusCh:     make(chan struct{}),
 		peerMap:         new(PeerMap),
+		peerStats:       new(peerStatsMap),
+		peerAccessList:  new(peerAccessList),
+		importResults:   new(importResultFeed),
+		sessions:        new(syncSessionSet),
+		closeCh:         make(chan struct{}),
 	}
 }
 
@@ -199,6 +272,27 @@ var (
 	}
 )
 
+func TestNewSyncer_Defaults(t *testing.T) {
+	t.Parallel()
+
+	syncer := NewSyncer(
+		hclog.NewNullLogger(),
+		&mockNetwork{},
+		&mockBlockchain{},
+		time.Second,
+	).(*syncer)
+
+	// extra behaviors are opt-in
+	assert.Zero(t, syncer.peerProbeInterval)
+	assert.Nil(t, syncer.syncSessionSem)
+	assert.Zero(t, syncer.syncBackoffBase)
+	assert.False(t, syncer.peerLatestBlockCheck)
+
+	client, _ := syncer.syncPeerClient.(*syncPeerClient)
+	assert.Zero(t, client.statusPublishInterval)
+	assert.True(t, client.shouldEmitBlocks.Load())
+}
+
 func Test_initializePeerMap(t *testing.T) {
 	t.Parallel()
 
@@ -400,45 +494,27 @@ func Test_startPeerDisconnectEventProcess(t *testing.T) {
 	}
 }
 
-func TestHasSyncPeer(t *testing.T) {
+func Test_initNewPeerStatus_Retry(t *testing.T) {
 	t.Parallel()
 
-	peerStatuses := []*NoForkPeer{
-		{
-			ID:       peer.ID("A"),
-			Number:   10,
-			Distance: big.NewInt(10),
-		},
-		{
-			ID:       peer.ID("B"),
-			Number:   20,
-			Distance: big.NewInt(20),
-		},
-	}
-
 	tests := []struct {
-		name        string
-		localLatest uint64
-		peers       []*NoForkPeer
-		result      bool
+		name string
+		// number of status requests failing before the first successful one
+		failures int
+		stored   bool
+		attempts int
 	}{
 		{
-			name:        "should return true when peerMap has elements",
-			localLatest: 0,
-			peers:       peerStatuses,
-			result:      true,
-		},
-		{
-			name:        "should return false when peerMap is empty",
-			localLatest: 0,
-			peers:       nil,
-			result:      false,
+			name:     "should store peer once status request succeeds after transient failure",
+			failures: 1,
+			stored:   true,
+			attempts: 2,
 		},
 		{
-			name:        "should return false when local latest is greater than any peers in peerMap",
-			localLatest: 30,
-			peers:       peerStatuses,
-			result:      false,
+			name:     "should skip peer failing every status request",
+			failures: peerStatusAttempts,
+			stored:   false,
+			attempts: peerStatusAttempts,
 		},
 	}
 
@@ -448,153 +524,100 @@ func TestHasSyncPeer(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
+			attempts := 0
+
 			syncer := NewTestSyncer(
 				nil,
-				&mockBlockchain{
-					headerHandler: newSimpleHeaderHandler(test.localLatest),
-				},
+				nil,
 				0,
-				&mockSyncPeerClient{},
+				&mockSyncPeerClient{
+					getPeerStatusHandler: func(id peer.ID) (*NoForkPeer, error) {
+						attempts++
+
+						if attempts <= test.failures {
+							return nil, errors.New("stream reset")
+						}
+
+						return &NoForkPeer{ID: id, Number: 10, Distance: big.NewInt(1)}, nil
+					},
+				},
 				&mockProgression{},
 			)
 
-			syncer.peerMap.Put(test.peers...)
+			syncer.initNewPeerStatus(peer.ID("A"))
 
-			assert.Equal(t, test.result, syncer.HasSyncPeer())
+			_, ok := syncer.peerMap.Load(peer.ID("A").String())
+
+			assert.Equal(t, test.stored, ok)
+			assert.Equal(t, test.attempts, attempts)
 		})
 	}
 }
 
-func blocksToCh(blocks []*types.Block, delay time.Duration) <-chan *types.Block {
-	ch := make(chan *types.Block)
-
-	go func() {
-		for _, b := range blocks {
-			time.Sleep(delay)
+func Test_probePeers(t *testing.T) {
+	t.Parallel()
 
-			ch <- b
-		}
+	const rtt = 50 * time.Millisecond
 
-		close(ch)
-	}()
+	syncer := NewTestSyncer(
+		nil,
+		nil,
+		0,
+		&mockSyncPeerClient{
+			getPeerStatusHandler: func(id peer.ID) (*NoForkPeer, error) {
+				if id == peer.ID("B") {
+					return nil, errors.New("peer is not responding")
+				}
 
-	return ch
-}
+				time.Sleep(rtt)
 
-func createMockBlocks(num int) []*types.Block {
-	blocks := make([]*types.Block, num)
-	for i := 0; i < num; i++ {
-		blocks[i] = &types.Block{
-			Header: &types.Header{
-				Number: uint64(i + 1),
+				return &NoForkPeer{ID: id, Number: 50, Distance: big.NewInt(0)}, nil
 			},
-		}
+		},
+		&mockProgression{},
+	)
+
+	syncer.peerMap.Put(peerStatuses[:2]...)
+
+	for i := 0; i < 3; i++ {
+		syncer.probePeers()
 	}
 
-	return blocks
+	latency := syncer.peerStats.get(peer.ID("A")).Latency()
+	assert.GreaterOrEqual(t, latency, rtt)
+	assert.Less(t, latency, 2*rtt)
+
+	// failed probe doesn't record latency but a failure
+	assert.Zero(t, syncer.peerStats.get(peer.ID("B")).Latency())
+	assert.Equal(t, uint64(3), syncer.peerStats.get(peer.ID("B")).Failures())
+
+	// status is refreshed without any gossip
+	assert.Equal(t, peer.ID("A"), syncer.peerMap.BestPeer(nil).ID)
+	assert.Equal(t, uint64(50), syncer.peerMap.BestPeer(nil).Number)
+	assert.Equal(t, uint64(20), syncer.peerMap.BestPeer(map[peer.ID]bool{peer.ID("A"): true}).Number)
 }
 
-func TestSync(t *testing.T) {
+func Test_probePeers_Ping(t *testing.T) {
 	t.Parallel()
 
-	blocks := createMockBlocks(10)
+	const rtt = 30 * time.Millisecond
 
 	tests := []struct {
 		name string
-
-		// local
-		beginningHeight     uint64
-		createBlockCallback func() func(*types.FullBlock) bool
-
-		// peers
-		peerStatuses []*NoForkPeer
-
-		peerBlocksCh   map[peer.ID]<-chan *types.Block
-		newStatusDelay time.Duration
-
-		// handlers
-		// a function to return a callback to use closure
-		createVerifyFinalizedBlockHandler func() func(*types.Block) (*types.FullBlock, error)
-
-		// results
-		blocks             []*types.Block
-		progressionStart   uint64
-		progressionHighest uint64
-		err                error
+		// whether peer A gossiped its status since the last probe
+		gossiped bool
+		// peers whose status is requested
+		statusRequested []peer.ID
 	}{
 		{
-			name:            "should sync blocks to the latest successfully",
-			beginningHeight: 0,
-			createBlockCallback: func() func(*types.FullBlock) bool {
-				return func(b *types.FullBlock) bool {
-					return b.Block.Number() >= 10
-				}
-			},
-			peerStatuses: []*NoForkPeer{
-				{
-					ID:       peer.ID("A"),
-					Number:   10,
-					Distance: big.NewInt(0),
-				},
-			},
-			newStatusDelay: 0,
-			peerBlocksCh: map[peer.ID]<-chan *types.Block{
-				peer.ID("A"): blocksToCh(blocks[:10], 0),
-			},
-			createVerifyFinalizedBlockHandler: func() func(*types.Block) (*types.FullBlock, error) {
-				return func(b *types.Block) (*types.FullBlock, error) {
-					return &types.FullBlock{Block: b}, nil
-				}
-			},
-			blocks:             blocks[:10],
-			progressionStart:   1,
-			progressionHighest: 10,
-			err:                nil,
+			name:            "should only ping the peer which gossiped its status",
+			gossiped:        true,
+			statusRequested: []peer.ID{peer.ID("B")},
 		},
 		{
-			name:            "should sync blocks with multiple peers",
-			beginningHeight: 0,
-			createBlockCallback: func() func(*types.FullBlock) bool {
-				return func(b *types.FullBlock) bool {
-					return b.Block.Number() >= 10
-				}
-			},
-			peerStatuses: []*NoForkPeer{
-				{
-					ID:       peer.ID("A"),
-					Number:   10,
-					Distance: big.NewInt(0),
-				},
-				{
-					ID:       peer.ID("B"),
-					Number:   10,
-					Distance: big.NewInt(1),
-				},
-			},
-			newStatusDelay: 0,
-			peerBlocksCh: map[peer.ID]<-chan *types.Block{
-				peer.ID("A"): blocksToCh(blocks[:10], 0),
-				peer.ID("B"): blocksToCh(blocks[4:10], 0),
-			},
-			createVerifyFinalizedBlockHandler: func() func(*types.Block) (*types.FullBlock, error) {
-				count := 0
-
-				return func(b *types.Block) (*types.FullBlock, error) {
-					if b.Number() == 5 {
-						count++
-
-						if count == 1 {
-							return nil, errors.New("block verification failed")
-						}
-					}
-
-					return &types.FullBlock{Block: b}, nil
-				}
-			},
-			blocks:             blocks[:10],
-			progressionStart:   1,
-			progressionHighest: 10,
-			err:                nil,
+			name:            "should request status of the pinged peer which didn't gossip",
+			gossiped:        false,
+			statusRequested: []peer.ID{peer.ID("A"), peer.ID("B")},
 		},
 	}
 
@@ -605,89 +628,1661 @@ func TestSync(t *testing.T) {
 			t.Parallel()
 
 			var (
-				syncedBlocks      = make([]*types.Block, 0, len(test.blocks))
-				latestBlockNumber = test.beginningHeight
-				progression       = &mockProgression{}
+				lock            sync.Mutex
+				pinged          []peer.ID
+				statusRequested []peer.ID
 
 				syncer = NewTestSyncer(
 					nil,
-					&mockBlockchain{
-						headerHandler:               newSimpleHeaderHandler(latestBlockNumber),
-						verifyFinalizedBlockHandler: test.createVerifyFinalizedBlockHandler(),
-						writeFullBlockHandler: func(b *types.FullBlock) error {
-							syncedBlocks = append(syncedBlocks, b.Block)
-							latestBlockNumber = b.Block.Number()
+					nil,
+					0,
+					&mockSyncPeerClient{
+						pingHandler: func(id peer.ID) (time.Duration, error) {
+							lock.Lock()
+							defer lock.Unlock()
 
-							return nil
+							pinged = append(pinged, id)
+
+							return rtt, nil
 						},
-					},
-					time.Second,
-					&mockSyncPeerClient{
-						getBlocksHandler: func(i peer.ID, u uint64, _ time.Duration) (<-chan *types.Block, error) {
-							// should not panic
-							peerCh := test.peerBlocksCh[i]
+						getPeerStatusHandler: func(id peer.ID) (*NoForkPeer, error) {
+							lock.Lock()
+							defer lock.Unlock()
+
+							statusRequested = append(statusRequested, id)
 
-							return peerCh, nil
+							return &NoForkPeer{ID: id, Number: 50, Distance: big.NewInt(0)}, nil
 						},
 					},
-					progression,
+					&mockProgression{},
 				)
 			)
 
-			errCh := make(chan error, 1)
+			WithPeerProbeInterval(time.Minute)(syncer)
 
-			go func() {
-				errCh <- syncer.Sync(test.createBlockCallback())
-			}()
+			// only peer A serves Ping
+			syncer.peerMap.Put(
+				&NoForkPeer{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(1), Capabilities: []string{FeaturePing}},
+				&NoForkPeer{ID: peer.ID("B"), Number: 10, Distance: big.NewInt(2)},
+			)
 
-			go func() {
-				for _, p := range test.peerStatuses {
-					syncer.peerMap.Put(p)
+			if test.gossiped {
+				syncer.peerStats.get(peer.ID("A")).recordStatus(time.Now())
+			}
 
-					syncer.newStatusCh <- struct{}{}
+			syncer.probePeers()
 
-					time.Sleep(test.newStatusDelay)
-				}
-			}()
+			lock.Lock()
+			defer lock.Unlock()
 
-			err := <-errCh
+			assert.Equal(t, []peer.ID{peer.ID("A")}, pinged)
+			assert.ElementsMatch(t, test.statusRequested, statusRequested)
 
-			assert.Equal(t, test.blocks, syncedBlocks)
-			assert.Equal(t, test.progressionStart, progression.startingBlock)
-			assert.Equal(t, test.progressionHighest, progression.highestBlock)
-			assert.ErrorIs(t, err, test.err)
+			// the round-trip time of the pinged peer is the one of Ping, not of the status request
+			assert.Equal(t, rtt, syncer.peerStats.get(peer.ID("A")).Latency())
 		})
 	}
 }
 
-func Test_bulkSyncWithPeer(t *testing.T) {
+func Test_probePeers_evictDeadPeer(t *testing.T) {
 	t.Parallel()
 
-	blockNum := 30
-	blocks := make([]*types.Block, blockNum) // 1 to 30
-
-	for i := 0; i < blockNum; i++ {
-		blocks[i] = &types.Block{
-			Header: &types.Header{
-				Number: uint64(i + 1),
-			},
-		}
-	}
-
 	var (
-		// mock errors
-		errPeerNoResponse       = errors.New("peer is not responding")
-		errInvalidBlock         = errors.New("invalid block")
-		errBlockInsertionFailed = errors.New("failed to insert block")
+		isDead   atomic.Bool
+		probeErr = errors.New("peer is not responding")
 	)
 
-	tests := []struct {
-		name string
-
-		// local
-		beginningHeight uint64
-		blockTimeout    time.Duration
-		blockCallback   func(*types.FullBlock) bool
+	syncer := NewTestSyncer(
+		nil,
+		nil,
+		0,
+		&mockSyncPeerClient{
+			getPeerStatusHandler: func(id peer.ID) (*NoForkPeer, error) {
+				if id == peer.ID("B") && isDead.Load() {
+					return nil, probeErr
+				}
+
+				return &NoForkPeer{ID: id, Number: 10, Distance: big.NewInt(0)}, nil
+			},
+		},
+		&mockProgression{},
+	)
+
+	WithMaxPeerProbeFailures(3)(syncer)
+
+	syncer.peerMap.Put(peerStatuses[:2]...)
+
+	isPeerInMap := func(id peer.ID) bool {
+		_, ok := syncer.peerMap.Load(id.String())
+
+		return ok
+	}
+
+	// B responds for a while, then stops
+	syncer.probePeers()
+	isDead.Store(true)
+
+	for i := 0; i < 2; i++ {
+		syncer.probePeers()
+
+		assert.True(t, isPeerInMap(peer.ID("B")))
+	}
+
+	// 3rd failure in a row evicts the peer
+	syncer.probePeers()
+
+	assert.False(t, isPeerInMap(peer.ID("B")))
+	assert.True(t, isPeerInMap(peer.ID("A")))
+}
+
+func Test_selectBestPeer(t *testing.T) {
+	t.Parallel()
+
+	peers := []*NoForkPeer{
+		{
+			// highest but slow and unreliable
+			ID:       peer.ID("A"),
+			Number:   30,
+			Distance: big.NewInt(0),
+		},
+		{
+			// slightly lower but fast and reliable
+			ID:       peer.ID("B"),
+			Number:   29,
+			Distance: big.NewInt(1),
+		},
+		{
+			// fastest but doesn't have a new block
+			ID:       peer.ID("C"),
+			Number:   10,
+			Distance: big.NewInt(2),
+		},
+	}
+
+	newSyncer := func() *syncer {
+		syncer := NewTestSyncer(nil, nil, 0, &mockSyncPeerClient{}, &mockProgression{})
+		syncer.peerMap.Put(peers...)
+
+		syncer.peerStats.get(peer.ID("A")).updateLatency(time.Second)
+
+		for i := 0; i < 5; i++ {
+			syncer.peerStats.get(peer.ID("A")).addFailure()
+		}
+
+		syncer.peerStats.get(peer.ID("B")).updateLatency(10 * time.Millisecond)
+		syncer.peerStats.get(peer.ID("C")).updateLatency(time.Millisecond)
+
+		return syncer
+	}
+
+	t.Run("should pick the highest peer by default", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, peers[0], newSyncer().selectBestPeer(nil, 10))
+	})
+
+	t.Run("should pick the peer with the best score if weights are set", func(t *testing.T) {
+		t.Parallel()
+
+		syncer := newSyncer()
+		WithPeerScoreWeights(DefaultPeerScoreWeights)(syncer)
+
+		assert.Equal(t, peers[1], syncer.selectBestPeer(nil, 10))
+	})
+
+	t.Run("should skip the peer in backoff after failed sync", func(t *testing.T) {
+		t.Parallel()
+
+		syncer := newSyncer()
+		WithSyncBackoff(time.Hour, time.Hour)(syncer)
+
+		syncer.addSyncFailure(peers[0].ID)
+
+		assert.NotEqual(t, peers[0], syncer.selectBestPeer(nil, 10))
+
+		syncer.peerStats.get(peers[0].ID).resetSyncFailures()
+
+		assert.Equal(t, peers[0], syncer.selectBestPeer(nil, 10))
+	})
+}
+
+func TestSetPeerAccessLists(t *testing.T) {
+	t.Parallel()
+
+	closedStreams := make([]peer.ID, 0)
+
+	syncer := NewTestSyncer(
+		nil,
+		nil,
+		0,
+		&mockSyncPeerClient{
+			closeStreamHandler: func(id peer.ID) error {
+				closedStreams = append(closedStreams, id)
+
+				return nil
+			},
+		},
+		&mockProgression{},
+	)
+
+	syncer.putToPeerMap(peerStatuses[0])
+	syncer.putToPeerMap(peerStatuses[1])
+
+	// A gets removed from peer map
+	syncer.SetPeerAccessLists(nil, []peer.ID{peer.ID("A")})
+
+	// A is rejected, C is accepted
+	syncer.putToPeerMap(peerStatuses[0])
+	syncer.putToPeerMap(peerStatuses[2])
+
+	assert.Equal(
+		t,
+		sortPeerStatuses([]*NoForkPeer{peerStatuses[1], peerStatuses[2]}),
+		sortPeerStatuses(GetAllElementsFromPeerMap(t, syncer.peerMap)),
+	)
+	assert.Equal(t, []peer.ID{peer.ID("A"), peer.ID("A")}, closedStreams)
+
+	// only B is allowed
+	syncer.SetPeerAccessLists([]peer.ID{peer.ID("B")}, nil)
+
+	assert.Equal(t, []*NoForkPeer{peerStatuses[1]}, GetAllElementsFromPeerMap(t, syncer.peerMap))
+}
+
+func TestDisconnectPeer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		network      = &mockNetwork{}
+		closedStream peer.ID
+
+		syncer = NewTestSyncer(
+			network,
+			&mockBlockchain{},
+			0,
+			&mockSyncPeerClient{
+				closeStreamHandler: func(id peer.ID) error {
+					closedStream = id
+
+					return nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	syncer.peerMap.Put(peerStatuses...)
+
+	assert.ErrorIs(t, syncer.DisconnectPeer(peer.ID("D")), errPeerNotFound)
+
+	assert.NoError(t, syncer.DisconnectPeer(peer.ID("A")))
+
+	_, ok := syncer.peerMap.Load(peer.ID("A").String())
+	assert.False(t, ok)
+	assert.ErrorIs(t, syncer.peerAccessList.check(peer.ID("A")), errPeerDisconnected)
+	assert.Equal(t, peer.ID("A"), closedStream)
+	assert.Equal(t, []peer.ID{peer.ID("A")}, network.disconnectedPeers)
+
+	// disconnected peer isn't put back on its next status
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 40, Distance: big.NewInt(1)})
+
+	_, ok = syncer.peerMap.Load(peer.ID("A").String())
+	assert.False(t, ok)
+
+	// nor once the access lists are replaced
+	syncer.SetPeerAccessLists(nil, nil)
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 40, Distance: big.NewInt(1)})
+
+	_, ok = syncer.peerMap.Load(peer.ID("A").String())
+	assert.False(t, ok)
+}
+
+func TestDisconnectPeer_Cooldown(t *testing.T) {
+	t.Parallel()
+
+	// no network, the peer is only dropped from peer map
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{},
+		0,
+		&mockSyncPeerClient{},
+		&mockProgression{},
+	)
+
+	WithDisconnectCooldown(100 * time.Millisecond)(syncer)
+
+	syncer.peerMap.Put(peerStatuses...)
+
+	assert.NoError(t, syncer.DisconnectPeer(peer.ID("A")))
+	assert.ErrorIs(t, syncer.peerAccessList.check(peer.ID("A")), errPeerDisconnected)
+
+	// the peer is accepted again once the cooldown passes
+	assert.Eventually(t, func() bool {
+		return syncer.peerAccessList.check(peer.ID("A")) == nil
+	}, time.Second, 10*time.Millisecond)
+
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 40, Distance: big.NewInt(1)})
+
+	_, ok := syncer.peerMap.Load(peer.ID("A").String())
+	assert.True(t, ok)
+}
+
+func TestClose_NotifyAfterClose(t *testing.T) {
+	t.Parallel()
+
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(0),
+		},
+		time.Second,
+		&mockSyncPeerClient{},
+		&mockProgression{},
+	)
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.Sync(func(*types.FullBlock) bool { return false })
+	}()
+
+	assert.NoError(t, syncer.Close())
+
+	// peer processes still stopping may notify of a status
+	assert.NotPanics(t, syncer.notifyNewStatusEvent)
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, errSyncerClosed)
+	case <-time.After(time.Second):
+		t.Fatal("sync didn't return after close")
+	}
+}
+
+func TestPutToPeerMap_RejectSelf(t *testing.T) {
+	t.Parallel()
+
+	var (
+		closedStreams []peer.ID
+
+		syncer = NewTestSyncer(
+			&mockNetwork{selfID: peer.ID("self")},
+			&mockBlockchain{},
+			0,
+			&mockSyncPeerClient{
+				closeStreamHandler: func(id peer.ID) error {
+					closedStreams = append(closedStreams, id)
+
+					return nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("self"), Number: 40, Distance: big.NewInt(0)})
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(1)})
+
+	_, ok := syncer.peerMap.Load(peer.ID("self").String())
+	assert.False(t, ok)
+
+	_, ok = syncer.peerMap.Load(peer.ID("A").String())
+	assert.True(t, ok)
+
+	assert.Empty(t, closedStreams)
+	assert.Equal(t, peer.ID("A"), syncer.peerMap.BestPeer(nil).ID)
+}
+
+func TestPutToPeerMap_MaxPeers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		closedStreams []peer.ID
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+			},
+			0,
+			&mockSyncPeerClient{
+				closeStreamHandler: func(id peer.ID) error {
+					closedStreams = append(closedStreams, id)
+
+					return nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithMaxPeers(2)(syncer)
+
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(1)})
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("B"), Number: 20, Distance: big.NewInt(1)})
+
+	// better peer replaces the worst one
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("C"), Number: 30, Distance: big.NewInt(1)})
+
+	// peer worse than all the others is rejected
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("D"), Number: 5, Distance: big.NewInt(1)})
+
+	// known peer is updated even though peer map is full
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("B"), Number: 40, Distance: big.NewInt(1)})
+
+	assert.Equal(t, 2, syncer.peerMap.Len())
+
+	for id, expected := range map[string]bool{"A": false, "B": true, "C": true, "D": false} {
+		_, ok := syncer.peerMap.Load(peer.ID(id).String())
+		assert.Equal(t, expected, ok, id)
+	}
+
+	assert.Equal(t, uint64(40), syncer.peerMap.BestPeer(nil).Number)
+	assert.Equal(t, []peer.ID{peer.ID("A"), peer.ID("D")}, closedStreams)
+}
+
+func TestPutToPeerMap_MaxPeersConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		maxPeers = 5
+		numPeers = 100
+	)
+
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(0),
+		},
+		0,
+		&mockSyncPeerClient{},
+		&mockProgression{},
+	)
+
+	WithMaxPeers(maxPeers)(syncer)
+
+	var (
+		wg     sync.WaitGroup
+		doneCh = make(chan struct{})
+		// largest peer map size seen while the peers were put
+		maxLen atomic.Int64
+	)
+
+	go func() {
+		for {
+			select {
+			case <-doneCh:
+				return
+			default:
+			}
+
+			if n := int64(syncer.peerMap.Len()); n > maxLen.Load() {
+				maxLen.Store(n)
+			}
+		}
+	}()
+
+	for i := 0; i < numPeers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			syncer.putToPeerMap(&NoForkPeer{
+				ID:       peer.ID(fmt.Sprintf("peer-%d", i)),
+				Number:   uint64(i + 1),
+				Distance: big.NewInt(1),
+			})
+		}(i)
+	}
+
+	wg.Wait()
+	close(doneCh)
+
+	assert.LessOrEqual(t, maxLen.Load(), int64(maxPeers))
+	assert.Equal(t, maxPeers, syncer.peerMap.Len())
+
+	// the best peers are kept whatever the order they were put in
+	assert.Equal(t, uint64(numPeers), syncer.peerMap.BestPeer(nil).Number)
+}
+
+func TestHasSyncPeer(t *testing.T) {
+	t.Parallel()
+
+	peerStatuses := []*NoForkPeer{
+		{
+			ID:       peer.ID("A"),
+			Number:   10,
+			Distance: big.NewInt(10),
+		},
+		{
+			ID:       peer.ID("B"),
+			Number:   20,
+			Distance: big.NewInt(20),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		localLatest uint64
+		margin      uint64
+		peers       []*NoForkPeer
+		result      bool
+	}{
+		{
+			name:        "should return true when peerMap has elements",
+			localLatest: 0,
+			peers:       peerStatuses,
+			result:      true,
+		},
+		{
+			name:        "should return false when peerMap is empty",
+			localLatest: 0,
+			peers:       nil,
+			result:      false,
+		},
+		{
+			name:        "should return false when local latest is greater than any peers in peerMap",
+			localLatest: 30,
+			peers:       peerStatuses,
+			result:      false,
+		},
+		{
+			name:        "should return false when best peer lead doesn't exceed sync margin",
+			localLatest: 19,
+			margin:      5,
+			peers:       peerStatuses,
+			result:      false,
+		},
+		{
+			name:        "should return true when best peer lead exceeds sync margin",
+			localLatest: 14,
+			margin:      5,
+			peers:       peerStatuses,
+			result:      true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{
+					headerHandler: newSimpleHeaderHandler(test.localLatest),
+				},
+				0,
+				&mockSyncPeerClient{},
+				&mockProgression{},
+			)
+
+			WithSyncMargin(test.margin)(syncer)
+
+			syncer.peerMap.Put(test.peers...)
+
+			assert.Equal(t, test.result, syncer.HasSyncPeer())
+		})
+	}
+}
+
+func TestPeerSyncProgress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		capabilities []string
+		// whether the peer is only known from its status requested on the call
+		notInPeerMap bool
+		expected     *SyncProgress
+	}{
+		{
+			name:         "should request the progress from the peer advertising the feature",
+			capabilities: []string{FeatureSyncProgress},
+			expected:     &SyncProgress{Head: 10, Highest: 20, Syncing: true},
+		},
+		{
+			name:     "should fall back to the latest block of the peer not advertising the feature",
+			expected: &SyncProgress{Head: 10, Highest: 10},
+		},
+		{
+			name:         "should request the status of the peer not in peer map",
+			capabilities: []string{FeatureSyncProgress},
+			notInPeerMap: true,
+			expected:     &SyncProgress{Head: 10, Highest: 20, Syncing: true},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				status = &NoForkPeer{
+					ID:           peer.ID("A"),
+					Number:       10,
+					Distance:     big.NewInt(1),
+					Capabilities: test.capabilities,
+				}
+				progressCalls = 0
+
+				syncer = NewTestSyncer(
+					nil,
+					&mockBlockchain{},
+					time.Second,
+					&mockSyncPeerClient{
+						getPeerStatusHandler: func(peer.ID) (*NoForkPeer, error) {
+							return status, nil
+						},
+						getPeerSyncProgressHandler: func(peer.ID) (*SyncProgress, error) {
+							progressCalls++
+
+							return &SyncProgress{Head: 10, Highest: 20, Syncing: true}, nil
+						},
+					},
+					&mockProgression{},
+				)
+			)
+
+			if !test.notInPeerMap {
+				syncer.peerMap.Put(status)
+			}
+
+			progress, err := syncer.PeerSyncProgress(peer.ID("A"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, progress)
+
+			// the RPC isn't sent to the peer which doesn't serve it
+			if !status.Supports(FeatureSyncProgress) {
+				assert.Zero(t, progressCalls)
+			}
+		})
+	}
+}
+
+func TestHighestKnownBlock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		peers          []*NoForkPeer
+		expectedNumber uint64
+		expectedPeerID peer.ID
+	}{
+		{
+			name: "should return the highest block and the peer advertising it",
+			peers: []*NoForkPeer{
+				{
+					ID:       peer.ID("A"),
+					Number:   10,
+					Distance: big.NewInt(10),
+				},
+				{
+					ID:       peer.ID("B"),
+					Number:   30,
+					Distance: big.NewInt(20),
+				},
+				{
+					ID:       peer.ID("C"),
+					Number:   20,
+					Distance: big.NewInt(30),
+				},
+			},
+			expectedNumber: 30,
+			expectedPeerID: peer.ID("B"),
+		},
+		{
+			name:           "should return zero values when peerMap is empty",
+			peers:          nil,
+			expectedNumber: 0,
+			expectedPeerID: "",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{},
+				0,
+				&mockSyncPeerClient{},
+				&mockProgression{},
+			)
+
+			syncer.peerMap.Put(test.peers...)
+
+			number, peerID := syncer.HighestKnownBlock()
+
+			assert.Equal(t, test.expectedNumber, number)
+			assert.Equal(t, test.expectedPeerID, peerID)
+		})
+	}
+}
+
+func TestPeers(t *testing.T) {
+	t.Parallel()
+
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{},
+		0,
+		&mockSyncPeerClient{
+			downloadedBytes: map[peer.ID]uint64{
+				peer.ID("B"): 1024,
+			},
+		},
+		&mockProgression{},
+	)
+
+	assert.Empty(t, syncer.Peers())
+
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("B"), Number: 20, Distance: big.NewInt(2)},
+		&NoForkPeer{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(1)},
+		&NoForkPeer{ID: peer.ID("C"), Number: 30, Distance: big.NewInt(3)},
+	)
+
+	syncer.peerStats.get(peer.ID("A")).updateLatency(time.Second)
+	syncer.peerStats.get(peer.ID("C")).addFailure()
+
+	peers := syncer.Peers()
+
+	assert.Equal(t, []PeerInfo{
+		{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(1), Latency: time.Second},
+		{ID: peer.ID("B"), Number: 20, Distance: big.NewInt(2), DownloadedBytes: 1024},
+		{ID: peer.ID("C"), Number: 30, Distance: big.NewInt(3), Failures: 1},
+	}, peers)
+
+	// snapshot shouldn't share state with peer map
+	peers[0].Distance.SetInt64(100)
+
+	assert.Equal(t, big.NewInt(1), syncer.Peers()[0].Distance)
+}
+
+func blocksToCh(blocks []*types.Block, delay time.Duration) <-chan *types.Block {
+	ch := make(chan *types.Block)
+
+	go func() {
+		for _, b := range blocks {
+			time.Sleep(delay)
+
+			ch <- b
+		}
+
+		close(ch)
+	}()
+
+	return ch
+}
+
+func createMockBlocks(num int) []*types.Block {
+	blocks := make([]*types.Block, num)
+	for i := 0; i < num; i++ {
+		blocks[i] = &types.Block{
+			Header: &types.Header{
+				Number: uint64(i + 1),
+			},
+		}
+	}
+
+	return blocks
+}
+
+// statusWaiter waits until a sync to target subscribes to new statuses and returns its channel
+func statusWaiter(t *testing.T, s *syncer) chan struct{} {
+	t.Helper()
+
+	var waiter chan struct{}
+
+	if !assert.Eventually(t, func() bool {
+		s.statusWaitersLock.Lock()
+		defer s.statusWaitersLock.Unlock()
+
+		for ch := range s.statusWaiters {
+			waiter = ch
+
+			return true
+		}
+
+		return false
+	}, 5*time.Second, time.Millisecond) {
+		t.FailNow()
+	}
+
+	return waiter
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	blocks := createMockBlocks(10)
+
+	tests := []struct {
+		name string
+
+		// local
+		beginningHeight     uint64
+		createBlockCallback func() func(*types.FullBlock) bool
+
+		// peers
+		peerStatuses []*NoForkPeer
+
+		peerBlocks     map[peer.ID][]*types.Block
+		newStatusDelay time.Duration
+		// whether the best peer's latest block is verified before bulk sync
+		verifyLatestBlock bool
+
+		// handlers
+		// a function to return a callback to use closure
+		createVerifyFinalizedBlockHandler func() func(*types.Block) (*types.FullBlock, error)
+
+		// results
+		blocks             []*types.Block
+		progressionStart   uint64
+		progressionHighest uint64
+		err                error
+	}{
+		{
+			name:            "should sync blocks to the latest successfully",
+			beginningHeight: 0,
+			createBlockCallback: func() func(*types.FullBlock) bool {
+				return func(b *types.FullBlock) bool {
+					return b.Block.Number() >= 10
+				}
+			},
+			peerStatuses: []*NoForkPeer{
+				{
+					ID:       peer.ID("A"),
+					Number:   10,
+					Distance: big.NewInt(0),
+				},
+			},
+			newStatusDelay: 0,
+			peerBlocks: map[peer.ID][]*types.Block{
+				peer.ID("A"): blocks[:10],
+			},
+			createVerifyFinalizedBlockHandler: func() func(*types.Block) (*types.FullBlock, error) {
+				return func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				}
+			},
+			blocks:             blocks[:10],
+			progressionStart:   1,
+			progressionHighest: 10,
+			err:                nil,
+		},
+		{
+			name:            "should sync blocks with multiple peers",
+			beginningHeight: 0,
+			createBlockCallback: func() func(*types.FullBlock) bool {
+				return func(b *types.FullBlock) bool {
+					return b.Block.Number() >= 10
+				}
+			},
+			peerStatuses: []*NoForkPeer{
+				{
+					ID:       peer.ID("A"),
+					Number:   10,
+					Distance: big.NewInt(0),
+				},
+				{
+					ID:       peer.ID("B"),
+					Number:   10,
+					Distance: big.NewInt(1),
+				},
+			},
+			newStatusDelay: 0,
+			peerBlocks: map[peer.ID][]*types.Block{
+				peer.ID("A"): blocks[:10],
+				peer.ID("B"): blocks[4:10],
+			},
+			createVerifyFinalizedBlockHandler: func() func(*types.Block) (*types.FullBlock, error) {
+				count := 0
+
+				return func(b *types.Block) (*types.FullBlock, error) {
+					if b.Number() == 5 {
+						count++
+
+						if count == 1 {
+							return nil, errors.New("block verification failed")
+						}
+					}
+
+					return &types.FullBlock{Block: b}, nil
+				}
+			},
+			blocks: blocks[:10],
+			// the session with B starts where the one with A failed
+			progressionStart:   5,
+			progressionHighest: 10,
+			err:                nil,
+		},
+		{
+			name:              "should skip a peer that can't serve its advertised latest block",
+			beginningHeight:   0,
+			verifyLatestBlock: true,
+			createBlockCallback: func() func(*types.FullBlock) bool {
+				return func(b *types.FullBlock) bool {
+					return b.Block.Number() >= 10
+				}
+			},
+			peerStatuses: []*NoForkPeer{
+				{
+					ID:       peer.ID("A"),
+					Number:   20,
+					Distance: big.NewInt(0),
+				},
+				{
+					ID:       peer.ID("B"),
+					Number:   10,
+					Distance: big.NewInt(1),
+				},
+			},
+			newStatusDelay: 0,
+			peerBlocks: map[peer.ID][]*types.Block{
+				peer.ID("A"): blocks[:10],
+				peer.ID("B"): blocks[:10],
+			},
+			createVerifyFinalizedBlockHandler: func() func(*types.Block) (*types.FullBlock, error) {
+				return func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				}
+			},
+			blocks:             blocks[:10],
+			progressionStart:   1,
+			progressionHighest: 10,
+			err:                nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				syncedBlocks      = make([]*types.Block, 0, len(test.blocks))
+				latestBlockNumber = test.beginningHeight
+				progression       = &mockProgression{}
+
+				syncer = NewTestSyncer(
+					nil,
+					&mockBlockchain{
+						// the next session continues from the blocks written by the previous one
+						headerHandler: func() *types.Header {
+							return &types.Header{Number: latestBlockNumber}
+						},
+						verifyFinalizedBlockHandler: test.createVerifyFinalizedBlockHandler(),
+						writeFullBlockHandler: func(b *types.FullBlock) error {
+							syncedBlocks = append(syncedBlocks, b.Block)
+							latestBlockNumber = b.Block.Number()
+
+							return nil
+						},
+					},
+					time.Second,
+					&mockSyncPeerClient{
+						getBlocksHandler: func(i peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+							peerBlocks := make([]*types.Block, 0, len(test.peerBlocks[i]))
+
+							for _, b := range test.peerBlocks[i] {
+								if b.Number() >= from {
+									peerBlocks = append(peerBlocks, b)
+								}
+							}
+
+							return blocksToCh(peerBlocks, 0), nil
+						},
+					},
+					progression,
+				)
+			)
+
+			WithPeerLatestBlockVerification(test.verifyLatestBlock)(syncer)
+
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- syncer.Sync(test.createBlockCallback())
+			}()
+
+			go func() {
+				for _, p := range test.peerStatuses {
+					syncer.peerMap.Put(p)
+
+					syncer.newStatusCh <- struct{}{}
+
+					time.Sleep(test.newStatusDelay)
+				}
+			}()
+
+			err := <-errCh
+
+			assert.Equal(t, test.blocks, syncedBlocks)
+			assert.Equal(t, test.progressionStart, progression.startingBlock)
+			assert.Equal(t, test.progressionHighest, progression.highestBlock)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestSyncToTarget(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks       = createMockBlocks(100)
+		target       = uint64(50)
+		syncedBlocks = make([]*types.Block, 0, target)
+		progression  = &mockProgression{}
+		latestNumber = uint64(0)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: func() *types.Header {
+					return &types.Header{Number: latestNumber}
+				},
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					syncedBlocks = append(syncedBlocks, b.Block)
+					latestNumber = b.Block.Number()
+
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(_ peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
+			},
+			progression,
+		)
+	)
+
+	syncer.peerMap.Put(&NoForkPeer{
+		ID:       peer.ID("A"),
+		Number:   uint64(len(blocks)),
+		Distance: big.NewInt(0),
+	})
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.SyncToTarget(context.Background(), target)
+	}()
+
+	statusCh := statusWaiter(t, syncer)
+
+	statusCh <- struct{}{}
+
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, blocks[:target], syncedBlocks)
+	assert.Equal(t, target, progression.highestBlock)
+
+	// already reached the target, should return immediately
+	assert.NoError(t, syncer.SyncToTarget(context.Background(), target))
+}
+
+func TestSyncToTarget_HeadReachedElsewhere(t *testing.T) {
+	t.Parallel()
+
+	var (
+		latestNumber   atomic.Uint64
+		getBlocksCalls atomic.Int64
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: func() *types.Header {
+					return &types.Header{Number: latestNumber.Load()}
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+					getBlocksCalls.Add(1)
+
+					return blocksToCh(nil, 0), nil
+				},
+			},
+			&mockProgression{},
+		)
+
+		syncErrCh   = make(chan error, 1)
+		targetErrCh = make(chan error, 1)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Sync is running at the same time and must keep receiving the statuses
+	go func() {
+		syncErrCh <- syncer.sync(ctx, math.MaxUint64, syncer.newStatusCh, func(*types.FullBlock) bool {
+			return false
+		})
+	}()
+
+	go func() {
+		targetErrCh <- syncer.SyncToTarget(ctx, 5)
+	}()
+
+	statusCh := statusWaiter(t, syncer)
+
+	// consensus writes the blocks up to target
+	latestNumber.Store(5)
+
+	statusCh <- struct{}{}
+
+	select {
+	case err := <-targetErrCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync to target didn't return once the head reached target")
+	}
+
+	// the status still reaches Sync
+	syncer.newStatusCh <- struct{}{}
+
+	cancel()
+
+	assert.ErrorIs(t, <-syncErrCh, context.Canceled)
+	assert.Zero(t, getBlocksCalls.Load())
+}
+
+func TestSync_CanceledDuringPeerVerification(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requestedCh = make(chan struct{}, 1)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: newSimpleHeaderHandler(0),
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+					requestedCh <- struct{}{}
+
+					// the peer is slow to respond, the stream stays open
+					return make(chan *types.Block), nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	WithPeerLatestBlockVerification(true)(syncer)
+
+	syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(0)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.SyncToTarget(ctx, 10)
+	}()
+
+	statusWaiter(t, syncer) <- struct{}{}
+
+	<-requestedCh
+	cancel()
+
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+
+	// our own cancellation doesn't count against the peer
+	_, ok := syncer.peerMap.Load(peer.ID("A").String())
+	assert.True(t, ok)
+	assert.Zero(t, syncer.peerStats.get(peer.ID("A")).Failures())
+}
+
+func TestSync_MinSyncPeers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// timeout of waiting for minimum number of peers
+		timeout time.Duration
+		// whether second peer connects
+		connectSecondPeer bool
+	}{
+		{
+			name:              "should start syncing once minimum number of peers connected",
+			timeout:           0,
+			connectSecondPeer: true,
+		},
+		{
+			name:              "should start syncing with peers at hand after timeout",
+			timeout:           100 * time.Millisecond,
+			connectSecondPeer: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				blocks         = createMockBlocks(10)
+				target         = uint64(len(blocks))
+				latestNumber   atomic.Uint64
+				getBlocksCalls atomic.Int64
+
+				syncer = NewTestSyncer(
+					nil,
+					&mockBlockchain{
+						headerHandler: func() *types.Header {
+							return &types.Header{Number: latestNumber.Load()}
+						},
+						verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+							return &types.FullBlock{Block: b}, nil
+						},
+						writeFullBlockHandler: func(b *types.FullBlock) error {
+							latestNumber.Store(b.Block.Number())
+
+							return nil
+						},
+					},
+					time.Second,
+					&mockSyncPeerClient{
+						getBlocksHandler: func(_ peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+							getBlocksCalls.Add(1)
+
+							return blocksToCh(blocks[from-1:], 0), nil
+						},
+					},
+					&mockProgression{},
+				)
+			)
+
+			WithMinSyncPeers(2, test.timeout)(syncer)
+
+			syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("A"), Number: target, Distance: big.NewInt(0)})
+
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- syncer.SyncToTarget(context.Background(), target)
+			}()
+
+			if test.connectSecondPeer {
+				statusCh := statusWaiter(t, syncer)
+
+				// the second event is received once the first one is handled
+				statusCh <- struct{}{}
+				statusCh <- struct{}{}
+
+				assert.False(t, syncer.MinSyncPeersReached())
+				assert.Equal(t, int64(0), getBlocksCalls.Load())
+
+				syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("B"), Number: target, Distance: big.NewInt(1)})
+				statusCh <- struct{}{}
+			}
+
+			select {
+			case err := <-errCh:
+				assert.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("sync didn't finish")
+			}
+
+			assert.True(t, syncer.MinSyncPeersReached())
+			assert.Equal(t, target, latestNumber.Load())
+		})
+	}
+}
+
+func Test_verifyAndWriteBlock_RetryWrite(t *testing.T) {
+	t.Parallel()
+
+	var (
+		block          = createMockBlocks(1)[0]
+		errWriteFailed = errors.New("database is busy")
+		writeCalls     = 0
+	)
+
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(*types.FullBlock) error {
+				writeCalls++
+
+				if writeCalls < writeBlockAttempts {
+					return errWriteFailed
+				}
+
+				return nil
+			},
+		},
+		time.Second,
+		&mockSyncPeerClient{},
+		&mockProgression{},
+	)
+
+	fullBlock, err := syncer.verifyAndWriteBlock(context.Background(), block)
+
+	assert.NoError(t, err)
+	assert.Equal(t, block, fullBlock.Block)
+	assert.Equal(t, writeBlockAttempts, writeCalls)
+}
+
+func Test_verifyAndWriteBlock_NoRetry(t *testing.T) {
+	t.Parallel()
+
+	block := createMockBlocks(1)[0]
+
+	newSyncer := func(writeErr error, writeCalls *atomic.Int64) *syncer {
+		return NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(*types.FullBlock) error {
+					writeCalls.Add(1)
+
+					return writeErr
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{},
+			&mockProgression{},
+		)
+	}
+
+	t.Run("should not retry a block rejected by consensus", func(t *testing.T) {
+		t.Parallel()
+
+		var writeCalls atomic.Int64
+
+		syncer := newSyncer(fmt.Errorf("%w: invalid validator set", blockchain.ErrProcessHeaders), &writeCalls)
+
+		_, err := syncer.verifyAndWriteBlock(context.Background(), block)
+
+		assert.ErrorIs(t, err, errInvalidBlock)
+		assert.True(t, isPeerFault(err))
+		assert.Equal(t, int64(1), writeCalls.Load())
+	})
+
+	t.Run("should stop retrying once the sync is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		var writeCalls atomic.Int64
+
+		syncer := newSyncer(errors.New("database is busy"), &writeCalls)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := syncer.verifyAndWriteBlock(ctx, block)
+
+		assert.ErrorIs(t, err, errWriteBlockFailed)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, isPeerFault(err))
+		assert.Equal(t, int64(1), writeCalls.Load())
+	})
+}
+
+func TestSync_PeerFailureAttribution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                        string
+		verifyFinalizedBlockHandler func(*types.Block) (*types.FullBlock, error)
+		writeFullBlockHandler       func(*types.FullBlock) error
+		expectedFailures            uint64
+	}{
+		{
+			name: "should blame the peer for a block failing verification",
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return nil, errors.New("invalid signature")
+			},
+			writeFullBlockHandler: func(*types.FullBlock) error {
+				return nil
+			},
+			expectedFailures: 1,
+		},
+		{
+			name: "should not blame the peer for a block failing to be written",
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(*types.FullBlock) error {
+				return errors.New("disk is full")
+			},
+			expectedFailures: 0,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			blocks := createMockBlocks(5)
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{
+					headerHandler:               newSimpleHeaderHandler(0),
+					verifyFinalizedBlockHandler: test.verifyFinalizedBlockHandler,
+					writeFullBlockHandler:       test.writeFullBlockHandler,
+				},
+				time.Second,
+				&mockSyncPeerClient{
+					getBlocksHandler: func(_ peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+						return blocksToCh(blocks[from-1:], 0), nil
+					},
+				},
+				&mockProgression{},
+			)
+
+			syncer.peerMap.Put(&NoForkPeer{
+				ID:       peer.ID("A"),
+				Number:   uint64(len(blocks)),
+				Distance: big.NewInt(0),
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- syncer.SyncToTarget(ctx, uint64(len(blocks)))
+			}()
+
+			statusCh := statusWaiter(t, syncer)
+
+			// the second event is received once the sync with the peer is done
+			statusCh <- struct{}{}
+			statusCh <- struct{}{}
+
+			cancel()
+
+			assert.ErrorIs(t, <-errCh, context.Canceled)
+			assert.Equal(t, test.expectedFailures, syncer.peerStats.get(peer.ID("A")).Failures())
+		})
+	}
+}
+
+func TestForceResync(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks       = createMockBlocks(10)
+		latestNumber = uint64(3)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: func() *types.Header {
+					return &types.Header{Number: latestNumber}
+				},
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					latestNumber = b.Block.Number()
+
+					return nil
+				},
+			},
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(_ peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
+			},
+			&mockProgression{},
+		)
+	)
+
+	// not the best peer
+	syncer.peerMap.Put(
+		&NoForkPeer{ID: peer.ID("A"), Number: 10, Distance: big.NewInt(2)},
+		&NoForkPeer{ID: peer.ID("B"), Number: 20, Distance: big.NewInt(1)},
+	)
+
+	assert.ErrorIs(t, syncer.ForceResync(context.Background(), peer.ID("C")), errPeerNotFound)
+	assert.Equal(t, uint64(3), latestNumber)
+
+	assert.NoError(t, syncer.ForceResync(context.Background(), peer.ID("A")))
+	assert.Equal(t, uint64(10), latestNumber)
+}
+
+func Test_bulkSyncWithPeer_MaxSyncPeers(t *testing.T) {
+	t.Parallel()
+
+	const (
+		maxSyncPeers = 2
+		sessions     = 5
+	)
+
+	var (
+		blocks = createMockBlocks(1)
+
+		maxActiveLock sync.Mutex
+		maxActive     int64
+
+		syncer *syncer
+	)
+
+	syncer = NewTestSyncer(
+		nil,
+		&mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(0),
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				return nil
+			},
+		},
+		time.Second,
+		&mockSyncPeerClient{
+			getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+				maxActiveLock.Lock()
+				if active := syncer.ActiveSyncSessions(); active > maxActive {
+					maxActive = active
+				}
+				maxActiveLock.Unlock()
+
+				return blocksToCh(blocks, 100*time.Millisecond), nil
+			},
+		},
+		&mockProgression{},
+	)
+
+	syncer.syncSessionSem = make(chan struct{}, maxSyncPeers)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("A"), 1, func(*types.FullBlock) bool {
+				return false
+			})
+
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(maxSyncPeers), maxActive)
+	assert.Zero(t, syncer.ActiveSyncSessions())
+}
+
+func Test_bulkSyncWithPeer_ImportResults(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks         = createMockBlocks(5)
+		errWriteFailed = errors.New("failed to write block")
+	)
+
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(0),
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				if b.Block.Number() == 3 {
+					return errWriteFailed
+				}
+
+				return nil
+			},
+		},
+		time.Second,
+		&mockSyncPeerClient{
+			getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh(blocks, 0), nil
+			},
+		},
+		&mockProgression{},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultCh := syncer.SubscribeImportResults(ctx)
+
+	_, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("X"), 5, func(*types.FullBlock) bool {
+		return false
+	})
+	assert.ErrorIs(t, err, errWriteFailed)
+
+	cancel()
+
+	results := make([]*ImportResult, 0, 3)
+	for result := range resultCh {
+		results = append(results, result)
+	}
+
+	assert.Len(t, results, 3)
+
+	for i, result := range results {
+		assert.Equal(t, peer.ID("X"), result.PeerID)
+		assert.Equal(t, blocks[i].Number(), result.Number)
+		assert.Equal(t, blocks[i].Hash(), result.Hash)
+	}
+
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+	assert.ErrorIs(t, results[2].Err, errWriteFailed)
+}
+
+func Test_bulkSyncWithPeer(t *testing.T) {
+	t.Parallel()
+
+	blockNum := 30
+	blocks := make([]*types.Block, blockNum) // 1 to 30
+
+	for i := 0; i < blockNum; i++ {
+		blocks[i] = &types.Block{
+			Header: &types.Header{
+				Number: uint64(i + 1),
+			},
+		}
+	}
+
+	var (
+		// mock errors
+		errPeerNoResponse       = errors.New("peer is not responding")
+		errInvalidBlock         = errors.New("invalid block")
+		errBlockInsertionFailed = errors.New("failed to insert block")
+	)
+
+	tests := []struct {
+		name string
+
+		// local
+		beginningHeight uint64
+		blockTimeout    time.Duration
+		blockCallback   func(*types.FullBlock) bool
 
 		// peers
 		getBlocksHandler func(id peer.ID, start uint64, timeoutPerBlock time.Duration) (<-chan *types.Block, error)
@@ -736,84 +2331,617 @@ func Test_bulkSyncWithPeer(t *testing.T) {
 			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
 				return &types.FullBlock{Block: b}, nil
 			},
-			writeFullBlockHandler: func(b *types.FullBlock) error {
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				return nil
+			},
+			blocks:                []*types.Block{},
+			lastSyncedBlockNumber: 0,
+			shouldTerminate:       false,
+			err:                   errPeerNoResponse,
+		},
+		{
+			name:            "should return error if verification is failed",
+			beginningHeight: 0,
+			blockTimeout:    time.Second,
+			blockCallback: func(b *types.FullBlock) bool {
+				return false
+			},
+			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh(blocks[:10], 0), nil
+			},
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				if b.Number() > 5 {
+					return nil, errInvalidBlock
+				}
+
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				return nil
+			},
+			blocks:                blocks[:5],
+			lastSyncedBlockNumber: 5,
+			shouldTerminate:       false,
+			err:                   errInvalidBlock,
+		},
+		{
+			name:            "should return error if block insertion is failed",
+			beginningHeight: 0,
+			blockTimeout:    time.Second,
+			blockCallback: func(b *types.FullBlock) bool {
+				return false
+			},
+			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh(blocks[:10], 0), nil
+			},
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				if b.Block.Number() > 5 {
+					return errBlockInsertionFailed
+				}
+
+				return nil
+			},
+			blocks:                blocks[:5],
+			lastSyncedBlockNumber: 5,
+			shouldTerminate:       false,
+			err:                   errBlockInsertionFailed,
+		},
+		{
+			name:            "should return error in case of timeout",
+			beginningHeight: 0,
+			blockTimeout:    500 * time.Millisecond,
+			blockCallback: func(b *types.FullBlock) bool {
+				return false
+			},
+			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh(blocks[:10], time.Second*1), nil
+			},
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				return nil
+			},
+			blocks:                []*types.Block{},
+			lastSyncedBlockNumber: 0,
+			shouldTerminate:       false,
+			err:                   errTimeout,
+		},
+		{
+			name:            "should return error if peer skips a block",
+			beginningHeight: 0,
+			blockTimeout:    time.Second,
+			blockCallback: func(b *types.FullBlock) bool {
+				return false
+			},
+			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh([]*types.Block{blocks[0], blocks[1], blocks[3]}, 0), nil
+			},
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				return nil
+			},
+			blocks:                blocks[:2],
+			lastSyncedBlockNumber: 2,
+			shouldTerminate:       false,
+			err:                   errBlockNotContiguous,
+		},
+		{
+			name:            "should return error if block doesn't link to the previous block",
+			beginningHeight: 0,
+			blockTimeout:    time.Second,
+			blockCallback: func(b *types.FullBlock) bool {
+				return false
+			},
+			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
+				mislinkedBlock := &types.Block{
+					Header: &types.Header{
+						Number:     3,
+						ParentHash: types.StringToHash("0x1"),
+					},
+				}
+
+				return blocksToCh([]*types.Block{blocks[0], blocks[1], mislinkedBlock}, 0), nil
+			},
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				return nil
+			},
+			blocks:                blocks[:2],
+			lastSyncedBlockNumber: 2,
+			shouldTerminate:       false,
+			err:                   errBlockNotContiguous,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				syncedBlocks = make([]*types.Block, 0, len(test.blocks))
+
+				syncer = NewTestSyncer(
+					nil,
+					&mockBlockchain{
+						headerHandler:               newSimpleHeaderHandler(test.beginningHeight),
+						verifyFinalizedBlockHandler: test.verifyFinalizedBlockHandler,
+						writeFullBlockHandler: func(b *types.FullBlock) error {
+							if err := test.writeFullBlockHandler(b); err != nil {
+								return err
+							}
+
+							syncedBlocks = append(syncedBlocks, b.Block)
+
+							return nil
+						},
+					},
+					test.blockTimeout,
+					&mockSyncPeerClient{
+						getBlocksHandler: test.getBlocksHandler,
+					},
+					&mockProgression{},
+				)
+			)
+
+			result, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("X"), test.lastSyncedBlockNumber, test.blockCallback)
+
+			assert.Equal(t, peer.ID("X"), result.PeerID)
+			assert.Equal(t, test.beginningHeight+1, result.StartHeight)
+			assert.Equal(t, test.lastSyncedBlockNumber, result.EndHeight)
+			assert.Equal(t, uint64(len(test.blocks)), result.BlocksWritten)
+			assert.Equal(t, test.shouldTerminate, result.ShouldTerminate)
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.blocks, syncedBlocks)
+		})
+	}
+}
+
+func Test_bulkSyncWithPeer_SyncError(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks          = createMockBlocks(5)
+		errInvalidSeal  = errors.New("invalid seal")
+		errDiskIsFull   = errors.New("disk is full")
+		errNoConnection = errors.New("no connection to peer")
+	)
+
+	tests := []struct {
+		name                        string
+		getBlocksHandler            func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error)
+		verifyFinalizedBlockHandler func(*types.Block) (*types.FullBlock, error)
+		writeFullBlockHandler       func(*types.FullBlock) error
+		expectedPhase               SyncPhase
+		expectedNumber              uint64
+		expectedErrs                []error
+	}{
+		{
+			name: "should return start error if blocks can't be requested",
+			getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+				return nil, errNoConnection
+			},
+			expectedPhase:  SyncPhaseStart,
+			expectedNumber: 1,
+			expectedErrs:   []error{errNoConnection},
+		},
+		{
+			name: "should return verify error if block is invalid",
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				if b.Number() == 3 {
+					return nil, errInvalidSeal
+				}
+
+				return &types.FullBlock{Block: b}, nil
+			},
+			expectedPhase:  SyncPhaseVerify,
+			expectedNumber: 3,
+			expectedErrs:   []error{errInvalidBlock, errInvalidSeal},
+		},
+		{
+			name: "should return write error if block can't be written",
+			writeFullBlockHandler: func(b *types.FullBlock) error {
+				if b.Block.Number() == 2 {
+					return errDiskIsFull
+				}
+
+				return nil
+			},
+			expectedPhase:  SyncPhaseWrite,
+			expectedNumber: 2,
+			expectedErrs:   []error{errWriteBlockFailed, errDiskIsFull},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if test.getBlocksHandler == nil {
+				test.getBlocksHandler = func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+					return blocksToCh(blocks, 0), nil
+				}
+			}
+
+			if test.verifyFinalizedBlockHandler == nil {
+				test.verifyFinalizedBlockHandler = func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				}
+			}
+
+			if test.writeFullBlockHandler == nil {
+				test.writeFullBlockHandler = func(*types.FullBlock) error {
+					return nil
+				}
+			}
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{
+					headerHandler:               newSimpleHeaderHandler(0),
+					verifyFinalizedBlockHandler: test.verifyFinalizedBlockHandler,
+					writeFullBlockHandler:       test.writeFullBlockHandler,
+				},
+				time.Second,
+				&mockSyncPeerClient{
+					getBlocksHandler: test.getBlocksHandler,
+				},
+				&mockProgression{},
+			)
+
+			_, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("X"), 5, func(*types.FullBlock) bool {
+				return false
+			})
+
+			var syncErr *SyncError
+
+			if assert.ErrorAs(t, err, &syncErr) {
+				assert.Equal(t, peer.ID("X"), syncErr.PeerID)
+				assert.Equal(t, test.expectedPhase, syncErr.Phase)
+				assert.Equal(t, test.expectedNumber, syncErr.Number)
+			}
+
+			for _, expectedErr := range test.expectedErrs {
+				assert.ErrorIs(t, err, expectedErr)
+			}
+		})
+	}
+}
+
+func Test_bulkSyncWithPeer_Metrics(t *testing.T) {
+	// not parallel, replaces the global metrics sink
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+
+	metricsConf := metrics.DefaultConfig("")
+	metricsConf.EnableHostname = false
+	metricsConf.EnableRuntimeMetrics = false
+
+	_, err := metrics.NewGlobal(metricsConf, sink)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, _ = metrics.NewGlobal(metricsConf, &metrics.BlackholeSink{})
+	})
+
+	blocks := createMockBlocks(5)
+
+	syncer := NewTestSyncer(
+		nil,
+		&mockBlockchain{
+			headerHandler: newSimpleHeaderHandler(0),
+			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+				return &types.FullBlock{Block: b}, nil
+			},
+			writeFullBlockHandler: func(*types.FullBlock) error {
 				return nil
 			},
-			blocks:                []*types.Block{},
-			lastSyncedBlockNumber: 0,
-			shouldTerminate:       false,
-			err:                   errPeerNoResponse,
 		},
-		{
-			name:            "should return error if verification is failed",
-			beginningHeight: 0,
-			blockTimeout:    time.Second,
-			blockCallback: func(b *types.FullBlock) bool {
-				return false
-			},
-			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
-				return blocksToCh(blocks[:10], 0), nil
+		time.Second,
+		&mockSyncPeerClient{
+			getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+				return blocksToCh(blocks, 0), nil
 			},
-			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
-				if b.Number() > 5 {
-					return nil, errInvalidBlock
-				}
+		},
+		&mockProgression{},
+	)
 
-				return &types.FullBlock{Block: b}, nil
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 5})
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("B"), Number: 3})
+
+	_, err = syncer.bulkSyncWithPeer(context.Background(), peer.ID("A"), 5, func(*types.FullBlock) bool {
+		return false
+	})
+	assert.NoError(t, err)
+
+	intervals := sink.Data()
+	assert.NotEmpty(t, intervals)
+
+	interval := intervals[len(intervals)-1]
+
+	assert.Equal(t, float32(2), interval.Gauges["syncer.peers"].Value)
+
+	if assert.Contains(t, interval.Counters, "syncer.imported_blocks") {
+		assert.Equal(t, 5, interval.Counters["syncer.imported_blocks"].Count)
+	}
+
+	if assert.Contains(t, interval.Samples, "syncer.session_duration") {
+		assert.Equal(t, 1, interval.Samples["syncer.session_duration"].Count)
+	}
+}
+
+func TestSync_SwitchToBetterPeer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks        = createMockBlocks(40)
+		target        = uint64(len(blocks))
+		latestNumber  atomic.Uint64
+		lock          sync.Mutex
+		writtenBlocks = make([]*types.Block, 0, len(blocks))
+		bulkRequests  = make(map[peer.ID][]uint64)
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: func() *types.Header {
+					return &types.Header{Number: latestNumber.Load()}
+				},
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					lock.Lock()
+					writtenBlocks = append(writtenBlocks, b.Block)
+					lock.Unlock()
+
+					latestNumber.Store(b.Block.Number())
+
+					return nil
+				},
 			},
-			writeFullBlockHandler: func(b *types.FullBlock) error {
-				return nil
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					// requests of the advertised latest block are verifications, not bulk syncs
+					if id == peer.ID("A") {
+						if from < 20 {
+							lock.Lock()
+							bulkRequests[id] = append(bulkRequests[id], from)
+							lock.Unlock()
+						}
+
+						// peer A is slow and only has 20 blocks
+						return blocksToCh(blocks[from-1:20], 20*time.Millisecond), nil
+					}
+
+					if from != target {
+						lock.Lock()
+						bulkRequests[id] = append(bulkRequests[id], from)
+						lock.Unlock()
+					}
+
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
 			},
-			blocks:                blocks[:5],
-			lastSyncedBlockNumber: 5,
-			shouldTerminate:       false,
-			err:                   errInvalidBlock,
-		},
-		{
-			name:            "should return error if block insertion is failed",
-			beginningHeight: 0,
-			blockTimeout:    time.Second,
-			blockCallback: func(b *types.FullBlock) bool {
-				return false
+			&mockProgression{},
+		)
+	)
+
+	WithPeerSwitchMargin(10)(syncer)
+
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 20, Distance: big.NewInt(0)})
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.SyncToTarget(context.Background(), target)
+	}()
+
+	statusCh := statusWaiter(t, syncer)
+
+	statusCh <- struct{}{}
+
+	// peer B joins while syncing with peer A
+	assert.Eventually(t, func() bool {
+		sessions := syncer.SyncSessions()
+
+		return len(sessions) == 1 && sessions[0].PeerID == peer.ID("A") && sessions[0].BlocksWritten() >= 3
+	}, 5*time.Second, 5*time.Millisecond)
+
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("B"), Number: target, Distance: big.NewInt(1)})
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync didn't switch to the better peer")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.Equal(t, blocks, writtenBlocks)
+	assert.Equal(t, []uint64{1}, bulkRequests[peer.ID("A")])
+
+	// peer B is synced with from the head reached with peer A
+	if assert.Len(t, bulkRequests[peer.ID("B")], 1) {
+		assert.Greater(t, bulkRequests[peer.ID("B")][0], uint64(3))
+		assert.Less(t, bulkRequests[peer.ID("B")][0], uint64(20))
+	}
+}
+
+func TestSync_SyncMargin(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks         = createMockBlocks(10)
+		latestNumber   atomic.Uint64
+		getBlocksCalls atomic.Int64
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: func() *types.Header {
+					return &types.Header{Number: latestNumber.Load()}
+				},
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					latestNumber.Store(b.Block.Number())
+
+					return nil
+				},
 			},
-			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
-				return blocksToCh(blocks[:10], 0), nil
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(_ peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					getBlocksCalls.Add(1)
+
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
 			},
-			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
-				return &types.FullBlock{Block: b}, nil
+			&mockProgression{},
+		)
+	)
+
+	WithSyncMargin(5)(syncer)
+
+	// peer one block ahead doesn't trigger bulk sync
+	syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("A"), Number: 1, Distance: big.NewInt(0)})
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.Sync(func(b *types.FullBlock) bool {
+			return b.Block.Number() >= uint64(len(blocks))
+		})
+	}()
+
+	// the second event is received once the first one is handled
+	syncer.newStatusCh <- struct{}{}
+	syncer.newStatusCh <- struct{}{}
+
+	assert.Equal(t, int64(0), getBlocksCalls.Load())
+	assert.Equal(t, uint64(0), latestNumber.Load())
+
+	// peer with a lead exceeding the margin triggers bulk sync
+	syncer.peerMap.Put(&NoForkPeer{ID: peer.ID("B"), Number: 10, Distance: big.NewInt(1)})
+
+	// the sync may pick B up while still handling the second event
+	select {
+	case syncer.newStatusCh <- struct{}{}:
+	case err := <-errCh:
+		errCh <- err
+	}
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync didn't finish")
+	}
+
+	assert.Equal(t, uint64(len(blocks)), latestNumber.Load())
+}
+
+func TestSync_StatusDuringSession(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocks       = createMockBlocks(10)
+		latestNumber atomic.Uint64
+
+		syncer = NewTestSyncer(
+			nil,
+			&mockBlockchain{
+				headerHandler: func() *types.Header {
+					return &types.Header{Number: latestNumber.Load()}
+				},
+				verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+					return &types.FullBlock{Block: b}, nil
+				},
+				writeFullBlockHandler: func(b *types.FullBlock) error {
+					latestNumber.Store(b.Block.Number())
+
+					return nil
+				},
 			},
-			writeFullBlockHandler: func(b *types.FullBlock) error {
-				if b.Block.Number() > 5 {
-					return errBlockInsertionFailed
-				}
+			time.Second,
+			&mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, from uint64, _ time.Duration) (<-chan *types.Block, error) {
+					if id == peer.ID("A") {
+						// peer A is slow and only has 5 blocks
+						return blocksToCh(blocks[from-1:5], 20*time.Millisecond), nil
+					}
 
-				return nil
+					return blocksToCh(blocks[from-1:], 0), nil
+				},
 			},
-			blocks:                blocks[:5],
-			lastSyncedBlockNumber: 5,
-			shouldTerminate:       false,
-			err:                   errBlockInsertionFailed,
+			&mockProgression{},
+		)
+	)
+
+	syncer.newStatusCh = make(chan struct{}, defaultNewStatusBufferSize)
+
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("A"), Number: 5, Distance: big.NewInt(0)})
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- syncer.Sync(func(b *types.FullBlock) bool {
+			return b.Block.Number() >= uint64(len(blocks))
+		})
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(syncer.SyncSessions()) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// the status received while syncing with peer A is kept until the session ends
+	syncer.putToPeerMap(&NoForkPeer{ID: peer.ID("B"), Number: 10, Distance: big.NewInt(1)})
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("status received during sync session was lost")
+	}
+
+	assert.Equal(t, uint64(len(blocks)), latestNumber.Load())
+}
+
+func Test_bulkSyncWithPeer_Deadline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// timeout of the whole session
+		sessionTimeout time.Duration
+		// time after which the caller cancels the sync, 0 means never
+		cancelAfter time.Duration
+		expectedErr error
+	}{
+		{
+			name:           "should abandon session exceeding session timeout",
+			sessionTimeout: 100 * time.Millisecond,
+			expectedErr:    context.DeadlineExceeded,
 		},
 		{
-			name:            "should return error in case of timeout",
-			beginningHeight: 0,
-			blockTimeout:    500 * time.Millisecond,
-			blockCallback: func(b *types.FullBlock) bool {
-				return false
-			},
-			getBlocksHandler: func(id peer.ID, start uint64, _ time.Duration) (<-chan *types.Block, error) {
-				return blocksToCh(blocks[:10], time.Second*1), nil
-			},
-			verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
-				return &types.FullBlock{Block: b}, nil
-			},
-			writeFullBlockHandler: func(b *types.FullBlock) error {
-				return nil
-			},
-			blocks:                []*types.Block{},
-			lastSyncedBlockNumber: 0,
-			shouldTerminate:       false,
-			err:                   errTimeout,
+			name:        "should return once the caller cancels the sync",
+			cancelAfter: 100 * time.Millisecond,
+			expectedErr: context.Canceled,
 		},
 	}
 
@@ -824,37 +2952,176 @@ func Test_bulkSyncWithPeer(t *testing.T) {
 			t.Parallel()
 
 			var (
-				syncedBlocks = make([]*types.Block, 0, len(test.blocks))
+				blocks        = createMockBlocks(100)
+				lock          sync.Mutex
+				writtenBlocks = make([]*types.Block, 0, len(blocks))
 
 				syncer = NewTestSyncer(
 					nil,
 					&mockBlockchain{
-						headerHandler:               newSimpleHeaderHandler(test.beginningHeight),
-						verifyFinalizedBlockHandler: test.verifyFinalizedBlockHandler,
+						headerHandler: newSimpleHeaderHandler(0),
+						verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+							return &types.FullBlock{Block: b}, nil
+						},
 						writeFullBlockHandler: func(b *types.FullBlock) error {
-							if err := test.writeFullBlockHandler(b); err != nil {
-								return err
-							}
+							lock.Lock()
+							defer lock.Unlock()
 
-							syncedBlocks = append(syncedBlocks, b.Block)
+							writtenBlocks = append(writtenBlocks, b.Block)
 
 							return nil
 						},
 					},
-					test.blockTimeout,
+					time.Second,
 					&mockSyncPeerClient{
-						getBlocksHandler: test.getBlocksHandler,
+						getBlocksHandler: func(peer.ID, uint64, time.Duration) (<-chan *types.Block, error) {
+							// the peer is slow but never misses the block timeout
+							return blocksToCh(blocks, 20*time.Millisecond), nil
+						},
 					},
 					&mockProgression{},
 				)
 			)
 
-			lastSynced, shouldTerminate, err := syncer.bulkSyncWithPeer(peer.ID("X"), test.lastSyncedBlockNumber, test.blockCallback)
+			WithSessionTimeout(test.sessionTimeout)(syncer)
 
-			assert.Equal(t, test.lastSyncedBlockNumber, lastSynced)
-			assert.Equal(t, test.shouldTerminate, shouldTerminate)
-			assert.ErrorIs(t, err, test.err)
-			assert.Equal(t, test.blocks, syncedBlocks)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if test.cancelAfter > 0 {
+				time.AfterFunc(test.cancelAfter, cancel)
+			}
+
+			start := time.Now()
+
+			result, err := syncer.bulkSyncWithPeer(ctx, peer.ID("A"), uint64(len(blocks)),
+				func(*types.FullBlock) bool { return false })
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			assert.False(t, isPeerFault(err))
+			assert.Less(t, time.Since(start), time.Second)
+			assert.False(t, result.TargetReached())
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			// the blocks written before the sync stopped are kept
+			assert.Equal(t, blocks[:len(writtenBlocks)], writtenBlocks)
+			assert.Equal(t, uint64(len(writtenBlocks)), result.BlocksWritten)
+			assert.Equal(t, result.BlocksWritten, result.EndHeight)
+		})
+	}
+}
+
+func Test_bulkSyncWithPeer_DownloadLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// whether the syncer can see that the download is held back by the limit
+		reportThrottling bool
+		// whether another stream from the same peer is held back by the limit instead
+		throttleOtherStream bool
+		expectedErr         error
+	}{
+		{
+			name:             "should keep waiting for blocks held back by the download limit",
+			reportThrottling: true,
+		},
+		{
+			name:        "should time out if blocks don't arrive for other reasons",
+			expectedErr: errTimeout,
+		},
+		{
+			name:                "should time out if only another stream from the peer is throttled",
+			throttleOtherStream: true,
+			expectedErr:         errTimeout,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				blocks = createMockBlocks(10)
+				// 5 blocks fit in the burst, each other block waits 200ms for the limit
+				client = &syncPeerClient{}
+				// blocks received on the stream but not passed on yet
+				pending atomic.Int32
+				// the stream of the session
+				streamCh <-chan *types.Block
+			)
+
+			WithPeerDownloadLimit(1000)(client)
+
+			mockClient := &mockSyncPeerClient{
+				getBlocksHandler: func(id peer.ID, _ uint64, _ time.Duration) (<-chan *types.Block, error) {
+					ch := make(chan *types.Block)
+
+					go func() {
+						defer close(ch)
+
+						for _, b := range blocks {
+							pending.Add(1)
+
+							if err := client.throttleDownload(context.Background(), id, 200); err != nil {
+								return
+							}
+
+							ch <- b
+
+							pending.Add(-1)
+						}
+					}()
+
+					streamCh = ch
+
+					return ch, nil
+				},
+			}
+
+			switch {
+			case test.reportThrottling:
+				mockClient.isThrottledHandler = func(ch <-chan *types.Block) bool {
+					return ch == streamCh && pending.Load() > 0
+				}
+			case test.throttleOtherStream:
+				mockClient.isThrottledHandler = func(ch <-chan *types.Block) bool {
+					return ch != streamCh
+				}
+			}
+
+			syncer := NewTestSyncer(
+				nil,
+				&mockBlockchain{
+					headerHandler: newSimpleHeaderHandler(0),
+					verifyFinalizedBlockHandler: func(b *types.Block) (*types.FullBlock, error) {
+						return &types.FullBlock{Block: b}, nil
+					},
+					writeFullBlockHandler: func(*types.FullBlock) error {
+						return nil
+					},
+				},
+				50*time.Millisecond,
+				mockClient,
+				&mockProgression{},
+			)
+
+			result, err := syncer.bulkSyncWithPeer(context.Background(), peer.ID("A"), uint64(len(blocks)),
+				func(*types.FullBlock) bool { return false })
+
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				assert.False(t, result.TargetReached())
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(len(blocks)), result.BlocksWritten)
 		})
 	}
 }
@@ -0,0 +1,87 @@
+package syncer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// TraceKind is the kind of a decision recorded in the sync trace
+type TraceKind string
+
+const (
+	// TracePeerScored is a peer scored while picking the best peer, Score is set
+	TracePeerScored TraceKind = "peer_scored"
+	// TracePeerSelected is the peer picked to sync with, To is its latest block
+	TracePeerSelected TraceKind = "peer_selected"
+	// TracePeerDropped is the peer skipped for failing to serve its latest block, Err is set
+	TracePeerDropped TraceKind = "peer_dropped"
+	// TraceSessionFinished is a finished bulk sync session, From and To are the blocks written
+	TraceSessionFinished TraceKind = "session_finished"
+	// TraceSegmentFinished is a segment downloaded in multi peer sync, or failed to be if Err is set
+	TraceSegmentFinished TraceKind = "segment_finished"
+)
+
+// TraceRecord is a decision of the syncer recorded in the sync trace
+type TraceRecord struct {
+	Time   time.Time
+	Kind   TraceKind
+	PeerID peer.ID
+	From   uint64
+	To     uint64
+	Score  float64
+	Err    error
+}
+
+// syncTracer keeps the latest sync trace records in a ring buffer,
+// nil tracer records nothing so that tracing costs nothing when disabled
+type syncTracer struct {
+	lock    sync.Mutex
+	records []TraceRecord
+	// index of the oldest record once the buffer is full
+	next int
+	full bool
+}
+
+// newSyncTracer returns a tracer keeping up to size latest records
+func newSyncTracer(size int) *syncTracer {
+	return &syncTracer{
+		records: make([]TraceRecord, size),
+	}
+}
+
+// record adds the record, overwriting the oldest one if the buffer is full
+func (t *syncTracer) record(record TraceRecord) {
+	if t == nil {
+		return
+	}
+
+	record.Time = time.Now().UTC()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.records[t.next] = record
+
+	t.next = (t.next + 1) % len(t.records)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// list returns the records kept, oldest first
+func (t *syncTracer) list() []TraceRecord {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.full {
+		return append([]TraceRecord(nil), t.records[:t.next]...)
+	}
+
+	return append(append([]TraceRecord(nil), t.records[t.next:]...), t.records[:t.next]...)
+}
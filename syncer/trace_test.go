@@ -0,0 +1,115 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_syncTracer(t *testing.T) {
+	t.Parallel()
+
+	var disabled *syncTracer
+
+	disabled.record(TraceRecord{Kind: TracePeerSelected})
+	assert.Nil(t, disabled.list())
+
+	tracer := newSyncTracer(3)
+
+	tracer.record(TraceRecord{Kind: TracePeerSelected, To: 1})
+	tracer.record(TraceRecord{Kind: TracePeerSelected, To: 2})
+
+	records := tracer.list()
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, uint64(1), records[0].To)
+		assert.False(t, records[0].Time.IsZero())
+	}
+
+	// oldest records are overwritten
+	tracer.record(TraceRecord{Kind: TracePeerSelected, To: 3})
+	tracer.record(TraceRecord{Kind: TracePeerSelected, To: 4})
+
+	numbers := make([]uint64, 0, 3)
+	for _, r := range tracer.list() {
+		numbers = append(numbers, r.To)
+	}
+
+	assert.Equal(t, []uint64{2, 3, 4}, numbers)
+}
+
+func TestSync_Trace(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genesis = (&types.Header{Number: 0}).ComputeHash()
+		chain   = createLinkedBlocks(genesis, 20)
+		// liar claims a far higher block than it has, so that it's scored best and dropped
+		liar   = &fakeSyncPeer{id: peer.ID("A"), blocks: chain[:10], advertisedNumber: 100}
+		honest = &fakeSyncPeer{id: peer.ID("B"), blocks: chain}
+
+		blockchain = newInMemoryBlockchain(genesis)
+
+		syncer = NewTestSyncer(
+			nil,
+			blockchain,
+			time.Second,
+			newFakeSyncPeerClient(liar, honest),
+			&mockProgression{},
+		)
+	)
+
+	WithPeerLatestBlockVerification(true)(syncer)
+	WithSyncTrace(64)(syncer)
+	WithPeerScoreWeights(DefaultPeerScoreWeights)(syncer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	syncer.initializePeerMap()
+
+	go func() {
+		for ctx.Err() == nil {
+			syncer.notifyNewStatusEvent()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	assert.NoError(t, syncer.SyncToTarget(ctx, uint64(len(chain))))
+
+	var scored, dropped, selected, finished []TraceRecord
+
+	for _, r := range syncer.SyncTrace() {
+		switch r.Kind {
+		case TracePeerScored:
+			scored = append(scored, r)
+		case TracePeerDropped:
+			dropped = append(dropped, r)
+		case TracePeerSelected:
+			selected = append(selected, r)
+		case TraceSessionFinished:
+			finished = append(finished, r)
+		}
+	}
+
+	assert.NotEmpty(t, scored)
+
+	if assert.Len(t, dropped, 1) {
+		assert.Equal(t, liar.id, dropped[0].PeerID)
+		assert.ErrorIs(t, dropped[0].Err, errPeerLatestBlockMissed)
+	}
+
+	if assert.Len(t, selected, 1) {
+		assert.Equal(t, honest.id, selected[0].PeerID)
+	}
+
+	if assert.Len(t, finished, 1) {
+		assert.Equal(t, honest.id, finished[0].PeerID)
+		assert.Equal(t, uint64(1), finished[0].From)
+		assert.Equal(t, uint64(len(chain)), finished[0].To)
+		assert.NoError(t, finished[0].Err)
+	}
+}
@@ -18,6 +18,19 @@ import (
 
 const syncerMetrics = "syncer"
 
+const (
+	// FeatureSyncProgress is advertised by the peers serving GetSyncProgress
+	FeatureSyncProgress = "sync-progress"
+	// FeaturePing is advertised by the peers serving Ping
+	FeaturePing = "ping"
+)
+
+// capabilities are the features advertised in own status
+var capabilities = []string{
+	FeatureSyncProgress,
+	FeaturePing,
+}
+
 type Blockchain interface {
 	// SubscribeEvents subscribes new blockchain event
 	SubscribeEvents() blockchain.Subscription
@@ -57,6 +70,8 @@ type Network interface {
 	SaveProtocolStream(protocol string, stream *rawGrpc.ClientConn, peerID peer.ID)
 	// CloseProtocolStream closes stream
 	CloseProtocolStream(protocol string, peerID peer.ID) error
+	// DisconnectFromPeer closes the connection to the peer
+	DisconnectFromPeer(peerID peer.ID, reason string)
 }
 
 type Syncer interface {
@@ -68,8 +83,30 @@ type Syncer interface {
 	GetSyncProgression() *progress.Progression
 	// HasSyncPeer returns whether syncer has the peer syncer can sync with
 	HasSyncPeer() bool
+	// HighestKnownBlock returns the highest block number advertised by the peers and the peer advertising it
+	HighestKnownBlock() (uint64, peer.ID)
+	// Peers returns a snapshot of the sync peers
+	Peers() []PeerInfo
+	// PeerSyncProgress returns the sync progress the peer reports of itself
+	PeerSyncProgress(peer.ID) (*SyncProgress, error)
 	// Sync starts routine to sync blocks
 	Sync(func(*types.FullBlock) bool) error
+	// SyncToTarget syncs blocks until local latest block reaches the given height
+	SyncToTarget(context.Context, uint64) error
+	// MultiPeerSync syncs blocks until the given height downloading ranges of blocks from several peers at once
+	MultiPeerSync(context.Context, uint64) error
+	// SyncSessions returns the bulk sync sessions in progress
+	SyncSessions() []*SyncSession
+	// SyncTrace returns the latest sync decisions recorded, nil if tracing is disabled
+	SyncTrace() []TraceRecord
+	// ForceResync runs bulk sync with the given peer regardless of the best peer selection
+	ForceResync(context.Context, peer.ID) error
+	// DisconnectPeer drops the peer and keeps it out until the disconnect cooldown passes
+	DisconnectPeer(peer.ID) error
+	// SetPeerAccessLists replaces allowlist and denylist of peers to sync with
+	SetPeerAccessLists(allowlist, denylist []peer.ID)
+	// SubscribeImportResults returns a channel of the results of importing blocks received from peers
+	SubscribeImportResults(context.Context) <-chan *ImportResult
 }
 
 type Progression interface {
@@ -99,14 +136,26 @@ type SyncPeerClient interface {
 	GetPeerStatus(id peer.ID) (*NoForkPeer, error)
 	// GetConnectedPeerStatuses fetches the statuses of all connecting peers
 	GetConnectedPeerStatuses() []*NoForkPeer
-	// GetBlocks returns a stream of blocks from given height to peer's latest
-	GetBlocks(peer.ID, uint64, time.Duration) (<-chan *types.Block, error)
+	// GetPeerSyncProgress fetches the sync progress the peer reports of itself
+	GetPeerSyncProgress(id peer.ID) (*SyncProgress, error)
+	// Ping pings the peer and returns the round-trip time
+	Ping(id peer.ID) (time.Duration, error)
+	// GetBlocks returns a stream of blocks from given height to peer's latest,
+	// the stream is closed once the given context is canceled
+	GetBlocks(context.Context, peer.ID, uint64, time.Duration) (<-chan *types.Block, error)
 	// GetPeerStatusUpdateCh returns a channel of peer's status update
 	GetPeerStatusUpdateCh() <-chan *NoForkPeer
 	// GetPeerConnectionUpdateEventCh returns peer's connection change event
 	GetPeerConnectionUpdateEventCh() <-chan *event.PeerEvent
 	// CloseStream close a stream
 	CloseStream(peerID peer.ID) error
+	// DownloadedBytes returns the number of bytes of blocks received from the connected peer
+	DownloadedBytes(peerID peer.ID) uint64
+	// TotalDownloadedBytes returns the number of bytes of blocks received from all peers
+	TotalDownloadedBytes() uint64
+	// IsThrottled returns true if a block received on the stream returned by GetBlocks
+	// is held back by the peer's download limit
+	IsThrottled(blockCh <-chan *types.Block) bool
 	// DisablePublishingPeerStatus disables publishing status in syncer topic
 	DisablePublishingPeerStatus()
 	// EnablePublishingPeerStatus enables publishing status in syncer topic